@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var cacheMaxAge time.Duration
+
+// openCache returns the cache for remote sources.
+func openCache() (*installer.Cache, error) {
+	dir, err := installer.DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	cache := installer.NewCache(dir)
+	cache.Auth = newAuth()
+	cache.HTTP = httpOptions()
+	cache.Extract = extractLimits()
+	return cache, nil
+}
+
+func runCacheList(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("The cache at %s is empty.\n", cache.Root())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tSOURCE\tSIZE\tFETCHED\tLAST USED")
+	for _, e := range entries {
+		source := e.Source
+		if e.Ref != "" {
+			source += "@" + e.Ref
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Kind, source, installer.FormatSize(e.Size),
+			e.Fetched.Local().Format("2006-01-02 15:04"), e.Used.Local().Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	results, err := cache.Prune(cacheMaxAge, dryRun)
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("Nothing to prune.")
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+	fmt.Printf("Cleared %s\n", cache.Root())
+	return nil
+}
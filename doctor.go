@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/config"
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+// severity ranks doctor findings. The process exit code is the worst
+// severity found: 0 for ok/info, 1 for warnings, 2 for errors.
+type severity int
+
+const (
+	sevOK severity = iota
+	sevInfo
+	sevWarning
+	sevError
+)
+
+func (s severity) String() string {
+	switch s {
+	case sevInfo:
+		return "INFO"
+	case sevWarning:
+		return "WARN"
+	case sevError:
+		return "ERROR"
+	default:
+		return "OK"
+	}
+}
+
+// finding is a single doctor result with a suggested fix.
+type finding struct {
+	Severity severity
+	Check    string
+	Message  string
+	Fix      string
+}
+
+// exitError makes a command exit with a specific code without printing an
+// error message.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if cfg != nil {
+		if targetType == "" {
+			targetType = cfg.Target
+		}
+		hostAuth = cfg.Auth
+		httpConfig = cfg.HTTP
+	}
+
+	scope := "project"
+	if globalInstall {
+		scope = "global"
+	}
+
+	var target Target
+	var manifest *installer.Manifest
+	if t, m, err := findInstalledTarget(scope); err == nil {
+		target, manifest = t, m
+	} else if targetType != "" {
+		target = targets[targetType]
+		if target.Name == "" {
+			return fmt.Errorf("unknown target: %s", targetType)
+		}
+	} else {
+		target = targets["claude"]
+	}
+	if manifest == nil {
+		manifest = installer.NewManifest(manifestRoot(target, scope))
+	}
+
+	fmt.Printf("Checking %s (%s)...\n\n", target.Name, scope)
+	findings := runDoctorChecks(cmd.Context(), target, scope, manifest, cfg)
+
+	worst := sevOK
+	for _, f := range findings {
+		fmt.Printf("[%-5s] %s: %s\n", f.Severity, f.Check, f.Message)
+		if f.Fix != "" {
+			fmt.Printf("        fix: %s\n", f.Fix)
+		}
+		if f.Severity > worst {
+			worst = f.Severity
+		}
+	}
+
+	switch worst {
+	case sevError:
+		fmt.Println("\nProblems found.")
+	case sevWarning:
+		fmt.Println("\nWarnings found.")
+	default:
+		fmt.Println("\nEverything looks good.")
+	}
+
+	if worst >= sevWarning {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return &exitError{code: int(worst - sevInfo)}
+	}
+	return nil
+}
+
+// runDoctorChecks runs every check against a target and scope.
+func runDoctorChecks(ctx context.Context, target Target, scope string, manifest *installer.Manifest, cfg *config.Config) []finding {
+	var findings []finding
+	findings = append(findings, checkHomeDir(target, scope)...)
+	findings = append(findings, checkTargetDirs(target, scope, manifest)...)
+	findings = append(findings, checkSkillFrontmatter(target, scope)...)
+	if scope == "project" {
+		findings = append(findings, checkGitignore(target)...)
+		findings = append(findings, checkConfigPlaceholders(target)...)
+	}
+	findings = append(findings, checkGitForSources(ctx, manifest, cfg)...)
+	return findings
+}
+
+// checkHomeDir verifies that global paths resolved from homeDir() are usable.
+func checkHomeDir(target Target, scope string) []finding {
+	if homeDir() == "" {
+		sev := sevWarning
+		if scope == "global" {
+			sev = sevError
+		}
+		return []finding{{
+			Severity: sev,
+			Check:    "home",
+			Message:  "home directory could not be determined; global paths resolve relative to the current directory",
+			Fix:      "set the HOME environment variable (USERPROFILE on Windows)",
+		}}
+	}
+	if target.GlobalSkillsPath != "" && !filepath.IsAbs(target.GlobalSkillsPath) {
+		return []finding{{
+			Severity: sevError,
+			Check:    "home",
+			Message:  fmt.Sprintf("global skills path %q is not absolute", target.GlobalSkillsPath),
+			Fix:      "set the HOME environment variable to an absolute path",
+		}}
+	}
+	return []finding{{Severity: sevOK, Check: "home", Message: fmt.Sprintf("home directory is %s", homeDir())}}
+}
+
+// checkTargetDirs verifies each of the target's directories exists and is
+// writable.
+func checkTargetDirs(target Target, scope string, manifest *installer.Manifest) []finding {
+	skillsDest, agentsDest, commandsDest := installDests(target, scope)
+	dirs := []struct {
+		kind string
+		path string
+	}{
+		{installer.KindSkill, skillsDest},
+		{installer.KindAgent, agentsDest},
+		{installer.KindCommand, commandsDest},
+	}
+
+	installedKinds := make(map[string]bool)
+	for _, f := range manifest.Files {
+		installedKinds[f.Kind] = true
+	}
+	if len(manifest.Links) > 0 {
+		installedKinds[installer.KindSkill] = true
+	}
+
+	var findings []finding
+	for _, d := range dirs {
+		if d.path == "" {
+			continue
+		}
+		info, err := os.Stat(d.path)
+		switch {
+		case os.IsNotExist(err) && installedKinds[d.kind]:
+			findings = append(findings, finding{
+				Severity: sevError,
+				Check:    "directories",
+				Message:  fmt.Sprintf("%s does not exist but the install manifest lists %ss in it", d.path, d.kind),
+				Fix:      "re-run skill-installer to restore it",
+			})
+		case os.IsNotExist(err):
+			findings = append(findings, finding{
+				Severity: sevInfo,
+				Check:    "directories",
+				Message:  fmt.Sprintf("%s does not exist yet", d.path),
+				Fix:      "run skill-installer to create it",
+			})
+		case err != nil:
+			findings = append(findings, finding{Severity: sevError, Check: "directories", Message: err.Error()})
+		case !info.IsDir():
+			findings = append(findings, finding{
+				Severity: sevError,
+				Check:    "directories",
+				Message:  fmt.Sprintf("%s is not a directory", d.path),
+				Fix:      fmt.Sprintf("move %s out of the way and re-run skill-installer", d.path),
+			})
+		case !dirWritable(d.path):
+			findings = append(findings, finding{
+				Severity: sevError,
+				Check:    "directories",
+				Message:  fmt.Sprintf("%s is not writable", d.path),
+				Fix:      fmt.Sprintf("check the permissions of %s", d.path),
+			})
+		default:
+			findings = append(findings, finding{Severity: sevOK, Check: "directories", Message: fmt.Sprintf("%s exists and is writable", d.path)})
+		}
+	}
+	return findings
+}
+
+// dirWritable reports whether a file can be created in dir.
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".skill-installer-doctor-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return true
+}
+
+// checkSkillFrontmatter verifies every skill directory, linked or copied,
+// has a SKILL.md with parseable frontmatter, and that links resolve.
+func checkSkillFrontmatter(target Target, scope string) []finding {
+	skillsDest, _, _ := installDests(target, scope)
+	entries, err := os.ReadDir(skillsDest)
+	if err != nil {
+		return nil
+	}
+
+	var findings []finding
+	checked := 0
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(skillsDest, e.Name())
+		// Linked skills are symlinks into the store; follow them
+		info, err := os.Stat(dir)
+		if err != nil && e.Type()&fs.ModeSymlink != 0 {
+			target, _ := os.Readlink(dir)
+			findings = append(findings, finding{
+				Severity: sevError,
+				Check:    "skills",
+				Message:  fmt.Sprintf("%s links to %s, which does not exist", dir, target),
+				Fix:      "re-run the install with --link to restore the store, or remove the link",
+			})
+			continue
+		}
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		skillMD := filepath.Join(dir, "SKILL.md")
+		data, err := os.ReadFile(skillMD)
+		if err != nil {
+			skillMD = filepath.Join(dir, "skill.md")
+			data, err = os.ReadFile(skillMD)
+		}
+		if err != nil {
+			findings = append(findings, finding{
+				Severity: sevWarning,
+				Check:    "skills",
+				Message:  fmt.Sprintf("%s has no SKILL.md", dir),
+				Fix:      fmt.Sprintf("add a SKILL.md or remove %s", dir),
+			})
+			continue
+		}
+		if _, err := installer.ParseSkill(data); err != nil {
+			findings = append(findings, finding{
+				Severity: sevError,
+				Check:    "skills",
+				Message:  fmt.Sprintf("%s: %v", skillMD, err),
+				Fix:      "add a frontmatter block with at least name and description (see 'skill-installer init')",
+			})
+			continue
+		}
+		checked++
+	}
+
+	if checked > 0 {
+		findings = append(findings, finding{Severity: sevOK, Check: "skills", Message: fmt.Sprintf("%d skill(s) have valid frontmatter", checked)})
+	}
+	return findings
+}
+
+// checkGitignore verifies .gitignore does not hide .claude/project.json or the
+// installed skills.
+func checkGitignore(target Target) []finding {
+	if !fileExists(".gitignore") {
+		return nil
+	}
+
+	var findings []finding
+	paths := []string{filepath.Join(target.SkillsPath, "x", "SKILL.md")}
+	if target.Name == "Claude Code" {
+		paths = append([]string{".claude/project.json"}, paths...)
+	}
+
+	for _, p := range paths {
+		ignored, err := gitIgnored(p)
+		if err != nil {
+			findings = append(findings, finding{Severity: sevInfo, Check: "gitignore", Message: fmt.Sprintf("could not check %s: %v", p, err)})
+			continue
+		}
+		display := p
+		fix := fmt.Sprintf("add !%s to .gitignore", p)
+		if strings.HasSuffix(p, "SKILL.md") {
+			display = target.SkillsPath + "/"
+			fix = fmt.Sprintf("add !%s/ to .gitignore after the rule that hides it", target.SkillsPath)
+		}
+		if ignored {
+			findings = append(findings, finding{
+				Severity: sevWarning,
+				Check:    "gitignore",
+				Message:  fmt.Sprintf("%s is ignored by git", display),
+				Fix:      fix,
+			})
+		} else {
+			findings = append(findings, finding{Severity: sevOK, Check: "gitignore", Message: fmt.Sprintf("%s is not ignored", display)})
+		}
+	}
+	return findings
+}
+
+// gitIgnored asks git whether a path is ignored, falling back to a plain
+// .gitignore scan when git is unavailable or this is not a repository.
+func gitIgnored(p string) (bool, error) {
+	if _, err := exec.LookPath("git"); err == nil {
+		if exec.Command("git", "rev-parse", "--is-inside-work-tree").Run() == nil {
+			err := exec.Command("git", "check-ignore", "-q", "--no-index", p).Run()
+			if err == nil {
+				return true, nil
+			}
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	data, err := os.ReadFile(".gitignore")
+	if err != nil {
+		return false, err
+	}
+	ignored := false
+	for _, line := range strings.Split(string(data), "\n") {
+		rule := strings.TrimSpace(line)
+		negate := strings.HasPrefix(rule, "!")
+		rule = strings.TrimPrefix(rule, "!")
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if gitignoreRuleMatches(strings.TrimPrefix(rule, "/"), p) {
+			ignored = !negate
+		}
+	}
+	return ignored, nil
+}
+
+// gitignoreRuleMatches is a simplified .gitignore matcher covering directory
+// rules (".claude/"), globs (".claude/*") and exact paths.
+func gitignoreRuleMatches(rule, p string) bool {
+	p = filepath.ToSlash(p)
+	if strings.HasSuffix(rule, "/") {
+		return strings.HasPrefix(p, rule)
+	}
+	if ok, _ := filepath.Match(rule, p); ok {
+		return true
+	}
+	// A rule matching a parent directory hides everything below it.
+	for dir := filepath.ToSlash(filepath.Dir(p)); dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+		if ok, _ := filepath.Match(rule, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConfigPlaceholders verifies the generated config file has no leftover
+// {{PLACEHOLDER}} tokens.
+func checkConfigPlaceholders(target Target) []finding {
+	if target.ConfigPath == "" {
+		return nil
+	}
+	f, err := os.Open(target.ConfigPath)
+	if err != nil {
+		return []finding{{
+			Severity: sevInfo,
+			Check:    "config",
+			Message:  fmt.Sprintf("%s does not exist", target.ConfigPath),
+			Fix:      "run skill-installer --mode config-only to generate it",
+		}}
+	}
+	defer f.Close()
+
+	var findings []finding
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		for _, token := range placeholderRe.FindAllString(scanner.Text(), -1) {
+			findings = append(findings, finding{
+				Severity: sevWarning,
+				Check:    "config",
+				Message:  fmt.Sprintf("%s:%d: unfilled placeholder %s", target.ConfigPath, lineNo, token),
+				Fix:      "replace the placeholder by hand or regenerate with skill-installer --mode config-only --force",
+			})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, finding{Severity: sevOK, Check: "config", Message: fmt.Sprintf("%s has no unfilled placeholders", target.ConfigPath)})
+	}
+	return findings
+}
+
+// checkGitForSources verifies git is on PATH when a git source is in use.
+func checkGitForSources(ctx context.Context, manifest *installer.Manifest, cfg *config.Config) []finding {
+	var sources []string
+	if !manifest.FromEmbedded() {
+		sources = append(sources, manifest.Source)
+	}
+	if cfg != nil && cfg.From != "" {
+		sources = append(sources, cfg.From)
+	}
+	if cfg != nil {
+		for _, s := range cfg.Sources {
+			switch {
+			case s.Type == installer.SourceGit || s.Ref != "":
+				sources = append(sources, "git::"+s.URL+s.Path)
+			case s.Type == "" && s.URL != "":
+				sources = append(sources, s.URL)
+			}
+		}
+	}
+
+	for _, src := range sources {
+		if !isGitSource(ctx, src) {
+			continue
+		}
+		if _, err := exec.LookPath("git"); err != nil {
+			return []finding{{
+				Severity: sevError,
+				Check:    "git",
+				Message:  fmt.Sprintf("git is not on PATH but %s is a git source", src),
+				Fix:      "install git or switch to a tarball URL",
+			}}
+		}
+		return []finding{{Severity: sevOK, Check: "git", Message: "git is available for git sources"}}
+	}
+	return nil
+}
+
+// isGitSource reports whether runFullInstall would clone src with git.
+func isGitSource(ctx context.Context, src string) bool {
+	src, _ = installer.SplitSubdir(src)
+	kind, _ := installer.ClassifySource(ctx, src, newAuth(), httpOptions())
+	return kind == installer.SourceGit
+}
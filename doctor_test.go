@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/config"
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+)
+
+// chdirTemp switches into a fresh temp directory for the duration of a test.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+	return dir
+}
+
+func worstSeverity(findings []finding) severity {
+	worst := sevOK
+	for _, f := range findings {
+		if f.Severity > worst {
+			worst = f.Severity
+		}
+	}
+	return worst
+}
+
+func TestCheckTargetDirs(t *testing.T) {
+	chdirTemp(t)
+	target := targets["claude"]
+	manifest := installer.NewManifest(".claude")
+
+	findings := checkTargetDirs(target, "project", manifest)
+	if worstSeverity(findings) != sevInfo {
+		t.Errorf("missing dirs without an install should be info, got %v", findings)
+	}
+
+	manifest.Record(installer.KindSkill, "x", filepath.Join(".claude", "skills", "x", "SKILL.md"), []byte("x"))
+	findings = checkTargetDirs(target, "project", manifest)
+	if worstSeverity(findings) != sevError {
+		t.Errorf("missing skills dir with installed skills should be an error, got %v", findings)
+	}
+
+	for _, d := range []string{".claude/skills", ".claude/agents", ".claude/commands"} {
+		os.MkdirAll(d, 0755)
+	}
+	findings = checkTargetDirs(target, "project", manifest)
+	if worstSeverity(findings) != sevOK || len(findings) != 3 {
+		t.Errorf("existing writable dirs should be ok, got %v", findings)
+	}
+}
+
+func TestCheckSkillFrontmatter(t *testing.T) {
+	chdirTemp(t)
+	os.MkdirAll(".claude/skills/good", 0755)
+	os.MkdirAll(".claude/skills/broken", 0755)
+	os.MkdirAll(".claude/skills/empty", 0755)
+	os.WriteFile(".claude/skills/good/SKILL.md", []byte("---\nname: good\ndescription: Good\n---\n"), 0644)
+	os.WriteFile(".claude/skills/broken/SKILL.md", []byte("# no frontmatter\n"), 0644)
+	os.MkdirAll("store/linked", 0755)
+	os.WriteFile("store/linked/SKILL.md", []byte("# no frontmatter\n"), 0644)
+	wd, _ := os.Getwd()
+	os.Symlink(filepath.Join(wd, "store", "linked"), ".claude/skills/linked")
+	os.Symlink(filepath.Join(wd, "store", "gone"), ".claude/skills/dangling")
+
+	findings := checkSkillFrontmatter(targets["claude"], "project")
+
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.Severity.String()+" "+f.Message)
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"ERROR .claude/skills/broken/SKILL.md",
+		"WARN .claude/skills/empty has no SKILL.md",
+		"ERROR .claude/skills/linked/SKILL.md",
+		"ERROR .claude/skills/dangling links to",
+		"OK 1 skill(s)",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("findings missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestCheckConfigPlaceholders(t *testing.T) {
+	chdirTemp(t)
+	target := targets["claude"]
+
+	if f := checkConfigPlaceholders(target); worstSeverity(f) != sevInfo {
+		t.Errorf("missing CLAUDE.md should be info, got %v", f)
+	}
+
+	os.WriteFile("CLAUDE.md", []byte("# Project\n\nRun {{TEST_COMMAND}} and {{BUILD_COMMAND}}\n"), 0644)
+	findings := checkConfigPlaceholders(target)
+	if len(findings) != 2 || worstSeverity(findings) != sevWarning {
+		t.Fatalf("expected 2 warnings, got %v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "CLAUDE.md:3: unfilled placeholder {{TEST_COMMAND}}") {
+		t.Errorf("unexpected message %q", findings[0].Message)
+	}
+}
+
+func TestGitignoreRuleMatches(t *testing.T) {
+	tests := []struct {
+		rule, path string
+		want       bool
+	}{
+		{".claude/", ".claude/project.json", true},
+		{".claude/*", ".claude/project.json", true},
+		{".claude/*", ".claude/skills/x/SKILL.md", true},
+		{".claude/project.json", ".claude/project.json", true},
+		{"*.md", "CLAUDE.md", true},
+		{"node_modules/", ".claude/project.json", false},
+		{".github/", ".claude/skills/x/SKILL.md", false},
+	}
+	for _, tt := range tests {
+		if got := gitignoreRuleMatches(tt.rule, tt.path); got != tt.want {
+			t.Errorf("gitignoreRuleMatches(%q, %q) = %v, want %v", tt.rule, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckGitignore(t *testing.T) {
+	chdirTemp(t)
+	target := targets["claude"]
+
+	if f := checkGitignore(target); len(f) != 0 {
+		t.Errorf("no .gitignore should produce no findings, got %v", f)
+	}
+
+	os.WriteFile(".gitignore", []byte(".claude/*\n!.claude/project.json\n!.claude/skills/\n"), 0644)
+	if f := checkGitignore(target); worstSeverity(f) != sevOK {
+		t.Errorf("negated rules should allow the paths, got %v", f)
+	}
+
+	os.WriteFile(".gitignore", []byte(".claude/\n"), 0644)
+	if f := checkGitignore(target); worstSeverity(f) != sevWarning || len(f) != 2 {
+		t.Errorf("ignored .claude/ should warn twice, got %v", f)
+	}
+}
+
+func TestCheckGitForSources(t *testing.T) {
+	manifest := installer.NewManifest(t.TempDir())
+	if f := checkGitForSources(context.Background(), manifest, nil); len(f) != 0 {
+		t.Errorf("embedded installs need no git check, got %v", f)
+	}
+
+	cfg := &config.Config{From: "https://github.com/org/skills"}
+	t.Setenv("PATH", t.TempDir())
+	if f := checkGitForSources(context.Background(), manifest, cfg); worstSeverity(f) != sevError {
+		t.Errorf("missing git for a git source should be an error, got %v", f)
+	}
+}
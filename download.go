@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/config"
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	httpTimeout time.Duration // --timeout
+	httpConfig  config.HTTP   // http: from the config
+
+	archiveConfig config.Archives // archives: from the config
+)
+
+// httpOptions returns the download settings from --timeout and the config's
+// http: section. Large downloads show a progress line when stderr is a
+// terminal.
+func httpOptions() installer.HTTPOptions {
+	opts := installer.HTTPOptions{
+		Timeout: httpConfig.Timeout,
+		Retries: httpConfig.Retries,
+		MaxSize: httpConfig.MaxSizeMB << 20,
+	}
+	if httpTimeout > 0 {
+		opts.Timeout = httpTimeout
+	}
+	if isTerminal(os.Stderr) {
+		opts.Progress = printProgress(os.Stderr)
+	}
+	return opts
+}
+
+// extractLimits returns the bounds on archive extraction from the config's
+// archives: section.
+func extractLimits() installer.ExtractLimits {
+	return installer.ExtractLimits{
+		MaxBytes:   archiveConfig.MaxSizeMB << 20,
+		MaxEntries: archiveConfig.MaxFiles,
+	}
+}
+
+// printProgress returns a progress callback that keeps rewriting one line
+// of w, ending it when the download is done.
+func printProgress(w io.Writer) func(installer.DownloadProgress) {
+	return func(p installer.DownloadProgress) {
+		line := fmt.Sprintf("Downloading %s: %s", p.URL, installer.FormatSize(p.Read))
+		if p.Total > 0 {
+			line += fmt.Sprintf(" of %s (%d%%)", installer.FormatSize(p.Total), p.Read*100/p.Total)
+		}
+		fmt.Fprintf(w, "\r%-79s", line)
+		if p.Done {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && !strings.EqualFold(os.Getenv("TERM"), "dumb")
+}
+
+// cmdContext returns the command's context, which is cancelled on Ctrl-C,
+// or a background context when there is no command (in tests).
+func cmdContext(cmd *cobra.Command) context.Context {
+	if cmd == nil || cmd.Context() == nil {
+		return context.Background()
+	}
+	return cmd.Context()
+}
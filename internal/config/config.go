@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,6 +16,58 @@ type Config struct {
 	SkipClaudeMD bool     `yaml:"skip_claude_md"`
 	From         string   `yaml:"from"`
 	Mode         string   `yaml:"mode"`
+	Link         bool     `yaml:"link"`
+	SHA256       string   `yaml:"sha256"`       // Expected SHA-256 of the archive named by From
+	TrustedKeys  []string `yaml:"trusted_keys"` // Keys whose signatures are accepted on archives
+	Registry     string   `yaml:"registry"`     // Registry index (URL or path) for search and add
+	Sources      []Source `yaml:"sources"`      // Sources whose skills are merged, instead of From
+	Auth         []Auth   `yaml:"auth"`         // Credentials for private source hosts
+	HTTP         HTTP     `yaml:"http"`         // Download timeouts, retries and size limit
+	Archives     Archives `yaml:"archives"`     // Limits on what archives may expand to
+	Lint         Lint     `yaml:"lint"`         // Settings for the lint command
+}
+
+// Lint configures the lint command.
+type Lint struct {
+	Tags []string `yaml:"tags"` // Tags allowed besides the standard vocabulary
+}
+
+// HTTP tunes downloads of sources and registry indexes. Zero values take
+// the defaults.
+type HTTP struct {
+	Timeout   time.Duration `yaml:"timeout"`     // e.g. 30s; applies to connecting and to stalls
+	Retries   int           `yaml:"retries"`     // After 5xx responses and dropped connections; -1 for none
+	MaxSizeMB int64         `yaml:"max_size_mb"` // Largest accepted download
+}
+
+// Archives bounds extraction, against decompression bombs. Zero values
+// take the defaults.
+type Archives struct {
+	MaxSizeMB int64 `yaml:"max_size_mb"` // Total uncompressed size
+	MaxFiles  int   `yaml:"max_files"`   // Files, directories, and links
+}
+
+// Auth names the environment variables holding the credentials for one
+// host. Set TokenEnv for bearer tokens, or UsernameEnv and PasswordEnv for
+// basic auth.
+type Auth struct {
+	Host        string `yaml:"host"`
+	TokenEnv    string `yaml:"token_env"`
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// Source is one entry of the sources: list.
+type Source struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"` // embedded, local, git or archive; detected from url/path if empty
+	URL      string   `yaml:"url"`
+	Path     string   `yaml:"path"`
+	Ref      string   `yaml:"ref"`      // Git tag, branch or commit
+	SHA256   string   `yaml:"sha256"`   // Expected SHA-256 of an archive
+	Priority int      `yaml:"priority"` // Higher wins when sources ship the same skill
+	Include  []string `yaml:"include"`  // Skill name patterns to take; empty takes all
+	Exclude  []string `yaml:"exclude"`  // Skill name patterns to leave out
 }
 
 // DefaultConfigFiles are the filenames to look for.
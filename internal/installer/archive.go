@@ -0,0 +1,431 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archive formats recognized by their leading bytes.
+const (
+	formatZip     = "zip"
+	formatTar     = "tar"
+	formatGzip    = "gzip"
+	formatZstd    = "zstd"
+	formatUnknown = ""
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	zipEmpty  = []byte("PK\x05\x06")
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// tarMagicOffset is where a tar header carries its "ustar" magic.
+const tarMagicOffset = 257
+
+// detectFormat identifies an archive from its first bytes. File names and
+// Content-Type headers are not trusted; GitHub and artifact stores routinely
+// serve archives under generic names.
+func detectFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, zipMagic), bytes.HasPrefix(header, zipEmpty):
+		return formatZip
+	case bytes.HasPrefix(header, gzipMagic):
+		return formatGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return formatZstd
+	case len(header) >= tarMagicOffset+5 && string(header[tarMagicOffset:tarMagicOffset+5]) == "ustar":
+		return formatTar
+	}
+	return formatUnknown
+}
+
+// Default extraction limits.
+const (
+	DefaultMaxExtractBytes   = 1 << 30
+	DefaultMaxExtractEntries = 20000
+)
+
+// ExtractLimits bounds what extracting an archive may write, so a
+// decompression bomb cannot fill the disk. Zero fields take the defaults.
+type ExtractLimits struct {
+	MaxBytes   int64 // Total uncompressed size of the files
+	MaxEntries int   // Files, directories, and links
+}
+
+func (l ExtractLimits) maxBytes() int64 {
+	if l.MaxBytes <= 0 {
+		return DefaultMaxExtractBytes
+	}
+	return l.MaxBytes
+}
+
+func (l ExtractLimits) maxEntries() int {
+	if l.MaxEntries <= 0 {
+		return DefaultMaxExtractEntries
+	}
+	return l.MaxEntries
+}
+
+// extractArchive unpacks the zip, tar, tar.gz or tar.zst archive at
+// archivePath into destDir, creating it, within limits.
+func extractArchive(archivePath, destDir string, limits ExtractLimits) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	x := &extractor{dest: filepath.Clean(destDir), limits: limits}
+	switch detectFormat(header[:n]) {
+	case formatZip:
+		var info os.FileInfo
+		if info, err = f.Stat(); err == nil {
+			err = x.zip(f, info.Size())
+		}
+	case formatTar:
+		err = x.tar(f)
+	case formatGzip:
+		var gzr *gzip.Reader
+		if gzr, err = gzip.NewReader(f); err == nil {
+			defer gzr.Close()
+			err = x.compressedTar(gzr)
+		}
+	case formatZstd:
+		var zr *zstd.Decoder
+		if zr, err = zstd.NewReader(f); err == nil {
+			defer zr.Close()
+			err = x.compressedTar(zr)
+		}
+	default:
+		return errors.New("unsupported archive format (expected zip, tar, tar.gz or tar.zst)")
+	}
+	if err != nil {
+		return err
+	}
+	return checkLinks(destDir)
+}
+
+// extractor writes archive entries under dest, keeping count of what it
+// wrote against the limits.
+type extractor struct {
+	dest    string
+	limits  ExtractLimits
+	written int64
+	entries int
+}
+
+// compressedTar extracts a decompressed stream, which must be a tar.
+func (x *extractor) compressedTar(r io.Reader) error {
+	br := bufio.NewReaderSize(r, 1024)
+	header, _ := br.Peek(512)
+	if detectFormat(header) != formatTar {
+		return errors.New("compressed file is not a tar archive")
+	}
+	return x.tar(br)
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader:
+			// PAX metadata, like the commit GitHub records; not a file
+			continue
+		case tar.TypeDir:
+			err = x.dir(header.Name)
+		case tar.TypeReg:
+			err = x.file(header.Name, tr, os.FileMode(header.Mode))
+		case tar.TypeSymlink:
+			err = x.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = x.hardlink(header.Name, header.Linkname)
+		default:
+			err = fmt.Errorf("unsupported entry %s in archive (type %q)", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.dir(zf.Name)
+		case mode&fs.ModeSymlink != 0:
+			err = x.zipSymlink(zf)
+		case mode.IsRegular():
+			err = x.zipFile(zf)
+		default:
+			err = fmt.Errorf("unsupported entry %s in archive (mode %s)", zf.Name, mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) zipFile(zf *zip.File) error {
+	if zf.UncompressedSize64 > uint64(x.limits.maxBytes()) {
+		return x.tooLarge()
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return x.file(zf.Name, rc, zf.Mode())
+}
+
+// zipSymlink extracts a zip symlink, whose content is the link target.
+func (x *extractor) zipSymlink(zf *zip.File) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	return x.symlink(zf.Name, string(target))
+}
+
+// entry counts an entry and resolves its name inside dest, rejecting names
+// that would escape it (zip-slip) or lead through a symlink the archive
+// created earlier.
+func (x *extractor) entry(name string) (string, error) {
+	x.entries++
+	if x.entries > x.limits.maxEntries() {
+		return "", fmt.Errorf("archive has more than %d entries", x.limits.maxEntries())
+	}
+	target, err := archiveTarget(x.dest, name)
+	if err != nil {
+		return "", err
+	}
+	for dir := filepath.Dir(target); dir != x.dest && len(dir) > len(x.dest); dir = filepath.Dir(dir) {
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("illegal path in archive: %s goes through a symlink", name)
+		}
+	}
+	return target, nil
+}
+
+func (x *extractor) dir(name string) error {
+	target, err := x.entry(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+// file writes a regular file. Permissions are reduced to 0755 for files
+// with any execute bit and 0644 for the rest; setuid, setgid, sticky and
+// write bits for others never survive.
+func (x *extractor) file(name string, r io.Reader, mode os.FileMode) error {
+	target, err := x.entry(name)
+	if err != nil {
+		return err
+	}
+	remaining := x.limits.maxBytes() - x.written
+	n, err := writeArchiveFile(target, io.LimitReader(r, remaining+1), sanitizeMode(mode))
+	x.written += n
+	if err != nil {
+		return err
+	}
+	if x.written > x.limits.maxBytes() {
+		return x.tooLarge()
+	}
+	return nil
+}
+
+func (x *extractor) tooLarge() error {
+	return fmt.Errorf("archive expands to more than %s", FormatSize(x.limits.maxBytes()))
+}
+
+// symlink creates a symlink, provided its target stays inside dest.
+func (x *extractor) symlink(name, linkname string) error {
+	target, err := x.entry(name)
+	if err != nil {
+		return err
+	}
+	if err := linkInside(x.dest, target, linkname); err != nil {
+		return fmt.Errorf("illegal symlink in archive: %s -> %s (%w)", name, linkname, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	os.Remove(target)
+	return os.Symlink(linkname, target)
+}
+
+// hardlink extracts a hard link as a copy of the file it links to, which
+// must be a regular file extracted earlier.
+func (x *extractor) hardlink(name, linkname string) error {
+	src, err := archiveTarget(x.dest, linkname)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(src)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("illegal hard link in archive: %s -> %s is not a file in the archive", name, linkname)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return x.file(name, f, info.Mode())
+}
+
+// linkInside checks that a symlink at link pointing to linkname resolves
+// inside dest, as far as the link's text goes. Absolute targets are never
+// accepted.
+func linkInside(dest, link, linkname string) error {
+	if linkname == "" || filepath.IsAbs(linkname) || strings.HasPrefix(linkname, "/") || filepath.VolumeName(linkname) != "" {
+		return errors.New("absolute target")
+	}
+	resolved := filepath.Join(filepath.Dir(link), filepath.FromSlash(linkname))
+	if !within(dest, resolved) {
+		return errors.New("escapes the destination")
+	}
+	return nil
+}
+
+// checkLinks verifies that every symlink under root resolves inside it.
+// Besides the checks on the link's text, the path it names may not pass
+// through another symlink, whose ".." would climb from somewhere else than
+// the text suggests; a final component that is a link is fine, as that
+// link is checked too.
+func checkLinks(root string) error {
+	root = filepath.Clean(root)
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+		linkname, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		err = linkInside(root, p, linkname)
+		if err == nil && traversesLink(filepath.Dir(p), linkname) {
+			err = errors.New("goes through another symlink")
+		}
+		if err != nil {
+			rel, _ := filepath.Rel(root, p)
+			return fmt.Errorf("illegal symlink in archive: %s -> %s (%w)", filepath.ToSlash(rel), linkname, err)
+		}
+		return nil
+	})
+}
+
+// traversesLink reports whether following linkname from dir passes through
+// a symlink before its last component.
+func traversesLink(dir, linkname string) bool {
+	parts := strings.Split(filepath.ToSlash(linkname), "/")
+	current := dir
+	for n, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+		current = filepath.Join(current, part)
+		if n == len(parts)-1 {
+			break
+		}
+		if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether p is dir or inside it. Both must be clean.
+func within(dir, p string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+}
+
+// sanitizeMode keeps only the execute intent of an archive entry's mode.
+func sanitizeMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// archiveTarget resolves an archive entry name inside destDir, rejecting
+// names that would escape it (zip-slip).
+func archiveTarget(destDir, name string) (string, error) {
+	target := filepath.Join(destDir, name)
+	if !within(filepath.Clean(destDir), target) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// writeArchiveFile writes an extracted file, creating its directory, and
+// returns the number of bytes written. A symlink already at target is
+// replaced rather than written through.
+func writeArchiveFile(target string, r io.Reader, mode os.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return 0, err
+		}
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
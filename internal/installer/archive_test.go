@@ -0,0 +1,357 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveFile is an entry in a test archive.
+type archiveFile struct {
+	name, content string
+}
+
+func tarBytes(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.content))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(data)
+	gw.Close()
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// archiveFormats builds the same entries in every supported format.
+func archiveFormats(t *testing.T, files []archiveFile) map[string][]byte {
+	return map[string][]byte{
+		"zip":     zipBytes(t, files),
+		"tar":     tarBytes(t, files),
+		"tar.gz":  gzipBytes(t, tarBytes(t, files)),
+		"tar.zst": zstdBytes(t, tarBytes(t, files)),
+	}
+}
+
+// writeTemp writes data to a file with a name that gives away nothing about
+// its format.
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "download")
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractArchive_Formats(t *testing.T) {
+	files := []archiveFile{
+		{"skills/one/SKILL.md", "---\nname: one\n---\n"},
+		{"skills/one/references/guide.md", "# Guide"},
+	}
+	for format, data := range archiveFormats(t, files) {
+		t.Run(format, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "out")
+			if err := extractArchive(writeTemp(t, data), dest, ExtractLimits{}); err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range files {
+				if got := readString(t, filepath.Join(dest, filepath.FromSlash(f.name))); got != f.content {
+					t.Errorf("%s = %q, want %q", f.name, got, f.content)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchive_RejectsEscapingPaths(t *testing.T) {
+	files := []archiveFile{{"../evil.md", "pwned"}}
+	for format, data := range archiveFormats(t, files) {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "out")
+			err := extractArchive(writeTemp(t, data), dest, ExtractLimits{})
+			if err == nil || !strings.Contains(err.Error(), "illegal path") {
+				t.Errorf("expected illegal path error, got %v", err)
+			}
+			if fileExists(filepath.Join(dir, "evil.md")) {
+				t.Error("file written outside the destination")
+			}
+		})
+	}
+}
+
+func TestExtractArchive_Unsupported(t *testing.T) {
+	tests := map[string][]byte{
+		"plain text":   []byte("not an archive"),
+		"gzipped text": gzipBytes(t, []byte("not a tar")),
+		"empty":        nil,
+	}
+	for name, data := range tests {
+		if err := extractArchive(writeTemp(t, data), filepath.Join(t.TempDir(), "out"), ExtractLimits{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// tarEntries builds a tar from raw headers, each followed by its content.
+func tarEntries(t testing.TB, headers ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		content := hdr.Linkname
+		if hdr.Typeflag != tar.TypeReg {
+			content = ""
+		} else {
+			hdr.Linkname = ""
+			hdr.Size = int64(len(content))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+// file, symlink and hardlink describe tar entries for tarEntries; a file's
+// content travels in Linkname.
+func file(name, content string, mode int64) *tar.Header {
+	return &tar.Header{Name: name, Linkname: content, Mode: mode, Typeflag: tar.TypeReg}
+}
+
+func symlink(name, target string) *tar.Header {
+	return &tar.Header{Name: name, Linkname: target, Mode: 0777, Typeflag: tar.TypeSymlink}
+}
+
+func hardlink(name, target string) *tar.Header {
+	return &tar.Header{Name: name, Linkname: target, Typeflag: tar.TypeLink}
+}
+
+func TestExtractArchive_Links(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*tar.Header
+		wantErr string
+	}{
+		{"link inside", []*tar.Header{
+			file("skills/one/SKILL.md", "---\nname: one\n---\n", 0644),
+			symlink("skills/one/GUIDE.md", "SKILL.md"),
+			symlink("skills/two", "one"),
+		}, ""},
+		{"link to parent", []*tar.Header{symlink("skills/up", "../..")}, "escapes the destination"},
+		{"absolute link", []*tar.Header{symlink("skills/passwd", "/etc/passwd")}, "absolute target"},
+		{"link through a link", []*tar.Header{
+			symlink("b", "a/../x"),
+			symlink("a", "."),
+		}, "goes through another symlink"},
+		{"write through a link", []*tar.Header{
+			symlink("skills", "."),
+			file("skills/one/SKILL.md", "x", 0644),
+		}, "goes through a symlink"},
+		{"hard link copies", []*tar.Header{
+			file("one/SKILL.md", "---\nname: one\n---\n", 0644),
+			hardlink("two/SKILL.md", "one/SKILL.md"),
+		}, ""},
+		{"hard link outside", []*tar.Header{hardlink("passwd", "../../etc/passwd")}, "illegal path"},
+		{"hard link to nothing", []*tar.Header{hardlink("x", "missing")}, "not a file in the archive"},
+		{"fifo", []*tar.Header{{Name: "pipe", Typeflag: tar.TypeFifo}}, "unsupported entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "out")
+			err := extractArchive(writeTemp(t, tarEntries(t, tt.entries...)), dest, ExtractLimits{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	dest := filepath.Join(t.TempDir(), "out")
+	err := extractArchive(writeTemp(t, tarEntries(t,
+		file("one/SKILL.md", "skill", 0644),
+		symlink("one/GUIDE.md", "SKILL.md"),
+		hardlink("two/SKILL.md", "one/SKILL.md"),
+	)), dest, ExtractLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(dest, "one", "GUIDE.md")); got != "skill" {
+		t.Errorf("GUIDE.md = %q", got)
+	}
+	if info, err := os.Lstat(filepath.Join(dest, "two", "SKILL.md")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("hard link not extracted as a copy: %v, %v", info, err)
+	}
+}
+
+func TestExtractArchive_ZipSymlink(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	hdr := &zip.FileHeader{Name: "evil"}
+	hdr.SetMode(os.ModeSymlink | 0777)
+	w, _ := zw.CreateHeader(hdr)
+	w.Write([]byte("../../.ssh/id_rsa"))
+	zw.Close()
+
+	err := extractArchive(writeTemp(t, buf.Bytes()), filepath.Join(t.TempDir(), "out"), ExtractLimits{})
+	if err == nil || !strings.Contains(err.Error(), "illegal symlink") {
+		t.Errorf("expected illegal symlink error, got %v", err)
+	}
+}
+
+func TestExtractArchive_Modes(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "out")
+	err := extractArchive(writeTemp(t, tarEntries(t,
+		file("run.sh", "#!/bin/sh\n", 04777),
+		file("notes.md", "notes", 0666),
+		file("bare.md", "no mode", 0),
+	)), dest, ExtractLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]os.FileMode{"run.sh": 0755, "notes.md": 0644, "bare.md": 0644}
+	for name, mode := range want {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The umask can only take bits away
+		if got := info.Mode(); got&^mode != 0 || got&os.ModeSetuid != 0 {
+			t.Errorf("%s mode = %v, want at most %v", name, got, mode)
+		}
+	}
+}
+
+func TestExtractArchive_Limits(t *testing.T) {
+	big := []archiveFile{{"a.md", strings.Repeat("a", 600)}, {"b.md", strings.Repeat("b", 600)}}
+	for format, data := range archiveFormats(t, big) {
+		t.Run(format, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "out")
+			err := extractArchive(writeTemp(t, data), dest, ExtractLimits{MaxBytes: 1000})
+			if err == nil || !strings.Contains(err.Error(), "expands to more than") {
+				t.Errorf("byte limit: %v", err)
+			}
+			if err := extractArchive(writeTemp(t, data), filepath.Join(t.TempDir(), "out"), ExtractLimits{MaxEntries: 1}); err == nil || !strings.Contains(err.Error(), "more than 1 entries") {
+				t.Errorf("entry limit: %v", err)
+			}
+			if err := extractArchive(writeTemp(t, data), filepath.Join(t.TempDir(), "out"), ExtractLimits{MaxBytes: 1200, MaxEntries: 2}); err != nil {
+				t.Errorf("within limits: %v", err)
+			}
+		})
+	}
+}
+
+func FuzzExtractArchive(f *testing.F) {
+	files := []archiveFile{{"skills/one/SKILL.md", "---\nname: one\n---\n"}, {"../evil.md", "x"}}
+	for _, data := range archiveFormats(&testing.T{}, files) {
+		f.Add(data)
+	}
+	f.Add(tarEntries(f, symlink("a", "."), symlink("b", "a/../x"), file("b/c", "x", 04777)))
+	f.Add(tarEntries(f, file("one/SKILL.md", "x", 0755), hardlink("two", "one/SKILL.md")))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "out")
+		err := extractArchive(writeTemp(t, data), dest, ExtractLimits{MaxBytes: 1 << 20, MaxEntries: 100})
+
+		// Nothing may land next to the destination, whatever the outcome
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.Name() != "out" {
+				t.Fatalf("extraction wrote %s outside the destination", e.Name())
+			}
+		}
+		if err != nil {
+			return
+		}
+		if err := checkLinks(dest); err != nil {
+			t.Fatalf("extraction succeeded with %v", err)
+		}
+		filepath.WalkDir(dest, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if info, err := d.Info(); err == nil && info.Mode()&(os.ModeSetuid|os.ModeSetgid|0002) != 0 {
+				t.Fatalf("%s extracted with mode %v", p, info.Mode())
+			}
+			return nil
+		})
+	})
+}
+
+func TestCache_FetchFile(t *testing.T) {
+	archive := writeTemp(t, zipBytes(t, []archiveFile{
+		{"skills-main/skills/one/SKILL.md", "---\nname: one\n---\n"},
+	}))
+	if kind, _ := ClassifySource(context.Background(), archive, nil, HTTPOptions{}); kind != SourceArchive {
+		t.Fatalf("local archive classified as %s", kind)
+	}
+
+	c, _ := testCache(t)
+	dir, err := c.FetchFile(archive, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "skills", "one")) {
+		t.Errorf("extracted %v", listTree(t, dir))
+	}
+	if again, err := c.FetchFile("file://"+archive, nil); err != nil || again != dir {
+		t.Errorf("file:// fetch = %s, %v", again, err)
+	}
+}
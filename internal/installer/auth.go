@@ -0,0 +1,241 @@
+package installer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// HostCredentials names the environment variables holding the credentials
+// for one host. Secrets themselves never live in the config.
+type HostCredentials struct {
+	Host        string // Host name, or host:port
+	TokenEnv    string // Variable holding a bearer token
+	UsernameEnv string // Variables holding basic auth credentials
+	PasswordEnv string
+}
+
+// Auth supplies credentials for downloads and git clones, per host: from
+// the configured environment variables first, then from a ~/.netrc entry
+// (basic auth).
+type Auth struct {
+	Hosts     []HostCredentials
+	NetrcPath string // Defaults to $NETRC, then ~/.netrc (~/_netrc on Windows)
+
+	getenv func(string) string
+}
+
+// NewAuth returns an Auth for the given hosts, falling back to ~/.netrc.
+func NewAuth(hosts []HostCredentials) *Auth {
+	return &Auth{Hosts: hosts, getenv: os.Getenv}
+}
+
+// authorization returns the Authorization header value for rawURL, or ""
+// when there are no credentials for its host.
+func (a *Auth) authorization(rawURL string) (string, error) {
+	if a == nil {
+		return "", nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", nil
+	}
+	getenv := a.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	for _, h := range a.Hosts {
+		if !strings.EqualFold(h.Host, u.Host) && !strings.EqualFold(h.Host, u.Hostname()) {
+			continue
+		}
+		if h.TokenEnv != "" {
+			token := getenv(h.TokenEnv)
+			if token == "" {
+				return "", fmt.Errorf("credentials for %s: $%s is not set", h.Host, h.TokenEnv)
+			}
+			return "Bearer " + token, nil
+		}
+		user, pass := getenv(h.UsernameEnv), getenv(h.PasswordEnv)
+		if h.UsernameEnv == "" || user == "" || pass == "" {
+			return "", fmt.Errorf("credentials for %s: set token_env, or username_env and password_env to variables that are set", h.Host)
+		}
+		return basicAuth(user, pass), nil
+	}
+
+	if user, pass, ok := a.netrc(u.Hostname()); ok {
+		return basicAuth(user, pass), nil
+	}
+	return "", nil
+}
+
+func basicAuth(user, pass string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+}
+
+// authorize adds the credentials for req's host, if any.
+func (a *Auth) authorize(req *http.Request) error {
+	header, err := a.authorization(req.URL.String())
+	if err != nil || header == "" {
+		return err
+	}
+	req.Header.Set("Authorization", header)
+	return nil
+}
+
+// gitEnv returns environment variables that make git send the credentials
+// for repoURL's host. They are passed as configuration through the
+// environment, so they appear neither in the process list nor in the
+// clone's .git/config.
+func (a *Auth) gitEnv(repoURL string) ([]string, error) {
+	header, err := a.authorization(repoURL)
+	if err != nil || header == "" {
+		return nil, err
+	}
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: " + header,
+	}, nil
+}
+
+// netrc looks up the login and password for host in the netrc file. A
+// "default" entry matches any host.
+func (a *Auth) netrc(host string) (string, string, bool) {
+	p := a.NetrcPath
+	if p == "" {
+		p = os.Getenv("NETRC")
+	}
+	if p == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		p = filepath.Join(home, name)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", "", false
+	}
+	return parseNetrc(string(data), host)
+}
+
+// parseNetrc finds the machine entry for host (or the default entry) in a
+// netrc file's content. Macro definitions are skipped.
+func parseNetrc(data, host string) (string, string, bool) {
+	var login, password string
+	matched, found := false, false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	inMacro := false
+	var tokens []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for n := 0; n < len(fields); n++ {
+			if fields[n] == "macdef" {
+				inMacro = true
+				break
+			}
+			if strings.HasPrefix(fields[n], "#") {
+				break
+			}
+			tokens = append(tokens, fields[n])
+		}
+	}
+
+	for n := 0; n < len(tokens); n++ {
+		switch tokens[n] {
+		case "machine", "default":
+			if matched {
+				return login, password, found
+			}
+			if tokens[n] == "default" {
+				matched = true
+			} else if n+1 < len(tokens) {
+				n++
+				matched = strings.EqualFold(tokens[n], host)
+			}
+		case "account":
+			n++
+		case "login", "password":
+			if n+1 >= len(tokens) {
+				break
+			}
+			n++
+			if !matched {
+				continue
+			}
+			if tokens[n-1] == "login" {
+				login = tokens[n]
+			} else {
+				password, found = tokens[n], true
+			}
+		}
+	}
+	return login, password, matched && found
+}
+
+// urlPattern finds URLs in free text such as git's error output.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s'"]+`)
+
+// RedactURL hides the secrets a URL may carry: the password in its user
+// info (or, for http(s), a lone user name, which is often a token) and the
+// values of its query parameters (tokens, signatures). The sha256 pin is
+// kept, as it is not a secret.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return rawURL
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	} else if u.User != nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.User = url.User("xxxxx")
+	}
+	if u.RawQuery != "" {
+		var params []string
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			key, value, ok := strings.Cut(param, "=")
+			if ok && key != "sha256" {
+				value = "xxxxx"
+			}
+			if ok {
+				param = key + "=" + value
+			}
+			params = append(params, param)
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
+
+// redactText applies RedactURL to every URL in s.
+func redactText(s string) string {
+	return urlPattern.ReplaceAllStringFunc(s, RedactURL)
+}
+
+// downloadError reports a failed download of rawURL without leaking the
+// secrets in it. The URL that net/http repeats in its errors is dropped.
+func downloadError(rawURL string, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return fmt.Errorf("downloading %s: %w", RedactURL(rawURL), err)
+}
@@ -0,0 +1,240 @@
+package installer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat names snapshot directories; it sorts chronologically.
+const backupTimeFormat = "20060102-150405"
+
+// backupIndexName is the file listing a snapshot's contents.
+const backupIndexName = "index.json"
+
+// BackupFile is a file saved in a snapshot.
+type BackupFile struct {
+	Path   string      `json:"path"` // Slash-separated, relative to the manifest root
+	SHA256 string      `json:"sha256"`
+	Mode   fs.FileMode `json:"mode"`
+}
+
+// Backup is a timestamped snapshot of files taken before they were
+// overwritten, stored in <root>/.skill-installer/backups/<id>/. The snapshot
+// directory is only created once the first file is saved.
+type Backup struct {
+	ID      string       `json:"id"`
+	Created time.Time    `json:"created"`
+	Files   []BackupFile `json:"files"`
+
+	root string
+}
+
+// NewBackup starts a snapshot for files belonging to the install rooted at
+// root (e.g. .claude).
+func NewBackup(root string) *Backup {
+	return &Backup{root: root}
+}
+
+// BackupsDir returns the directory holding the snapshots for root.
+func BackupsDir(root string) string {
+	return filepath.Join(root, StateDirName, "backups")
+}
+
+// Dir returns the snapshot's directory.
+func (b *Backup) Dir() string {
+	return filepath.Join(BackupsDir(b.root), b.ID)
+}
+
+// Save copies the current content of filePath into the snapshot. Missing
+// files are ignored, and a file already saved keeps its first copy.
+func (b *Backup) Save(filePath string) error {
+	info, err := os.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("backing up %s: %w", filePath, err)
+	}
+
+	rel, err := filepath.Rel(b.root, filePath)
+	if err != nil {
+		return fmt.Errorf("backing up %s: %w", filePath, err)
+	}
+	rel = filepath.ToSlash(rel)
+	for _, f := range b.Files {
+		if f.Path == rel {
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("backing up %s: %w", filePath, err)
+	}
+	if err := b.create(); err != nil {
+		return err
+	}
+
+	entry := BackupFile{Path: rel, SHA256: HashBytes(data), Mode: info.Mode().Perm()}
+	object := filepath.Join(b.Dir(), "files", entry.SHA256)
+	if !fileExists(object) {
+		if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+			return fmt.Errorf("creating directory %s: %w", filepath.Dir(object), err)
+		}
+		if err := os.WriteFile(object, data, 0644); err != nil {
+			return fmt.Errorf("backing up %s: %w", filePath, err)
+		}
+	}
+	b.Files = append(b.Files, entry)
+	return b.writeIndex()
+}
+
+// create picks a free snapshot ID and creates its directory on first use.
+func (b *Backup) create() error {
+	if b.ID != "" {
+		return nil
+	}
+	if err := ensureStateDir(b.root); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(BackupsDir(b.root), 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", BackupsDir(b.root), err)
+	}
+
+	b.Created = time.Now().UTC()
+	base := b.Created.Format(backupTimeFormat)
+	for n := 1; ; n++ {
+		b.ID = base
+		if n > 1 {
+			b.ID = fmt.Sprintf("%s-%d", base, n)
+		}
+		err := os.Mkdir(b.Dir(), 0755)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("creating backup: %w", err)
+		}
+	}
+}
+
+func (b *Backup) writeIndex() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(b.Dir(), backupIndexName)
+	if err := os.WriteFile(p, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", p, err)
+	}
+	return nil
+}
+
+// ListBackups returns the snapshots for root, oldest first.
+func ListBackups(root string) ([]Backup, error) {
+	entries, err := os.ReadDir(BackupsDir(root))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		b, err := loadBackup(root, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *b)
+	}
+	sort.Slice(backups, func(a, c int) bool { return backups[a].ID < backups[c].ID })
+	return backups, nil
+}
+
+func loadBackup(root, id string) (*Backup, error) {
+	p := filepath.Join(BackupsDir(root), id, backupIndexName)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var b Backup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", p, err)
+	}
+	b.root = root
+	b.ID = id
+	return &b, nil
+}
+
+// RestoreBackup writes the files of snapshot id back to where they came
+// from; an empty id restores the latest snapshot. Current content that would
+// be overwritten is itself snapshotted first, so a restore can be undone.
+func RestoreBackup(root, id string, dryRun bool) ([]string, error) {
+	if id == "" {
+		backups, err := ListBackups(root)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) == 0 {
+			return nil, fmt.Errorf("no backups found in %s", BackupsDir(root))
+		}
+		id = backups[len(backups)-1].ID
+	}
+	if strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid backup %q", id)
+	}
+
+	b, err := loadBackup(root, id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("backup %q not found in %s", id, BackupsDir(root))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	undo := NewBackup(root)
+	var results []string
+	for _, f := range b.Files {
+		target := filepath.Join(root, filepath.FromSlash(f.Path))
+		data, err := os.ReadFile(filepath.Join(b.Dir(), "files", f.SHA256))
+		if err != nil {
+			return results, fmt.Errorf("reading backup of %s: %w", target, err)
+		}
+
+		if current, err := HashFile(target); err == nil && current == f.SHA256 {
+			results = append(results, fmt.Sprintf("UNCHANGED: %s", target))
+			continue
+		}
+		if dryRun {
+			results = append(results, fmt.Sprintf("WOULD RESTORE: %s", target))
+			continue
+		}
+
+		if err := undo.Save(target); err != nil {
+			return results, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return results, fmt.Errorf("creating directory %s: %w", filepath.Dir(target), err)
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(target, data, mode); err != nil {
+			return results, fmt.Errorf("restoring %s: %w", target, err)
+		}
+		results = append(results, fmt.Sprintf("RESTORED: %s", target))
+	}
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBackup_ForcedInstallSavesOverwrittenFiles(t *testing.T) {
+	testFS := fstest.MapFS{
+		"skills/one/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: one\n---\nnew")},
+		"skills/two/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: two\n---\n")},
+	}
+	root := filepath.Join(t.TempDir(), ".claude")
+	one := filepath.Join(root, "skills", "one", "SKILL.md")
+	two := filepath.Join(root, "skills", "two", "SKILL.md")
+	os.MkdirAll(filepath.Dir(one), 0755)
+	os.MkdirAll(filepath.Dir(two), 0755)
+	os.WriteFile(one, []byte("hand edited"), 0644)
+	os.WriteFile(two, []byte("---\nname: two\n---\n"), 0644) // Identical, nothing lost
+
+	inst := New(testFS, Options{Force: true})
+	b := NewBackup(root)
+	inst.SetBackup(b)
+	if _, err := inst.InstallSkills(filepath.Join(root, "skills"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.Files) != 1 || b.Files[0].Path != "skills/one/SKILL.md" {
+		t.Fatalf("backed up %+v, want only skills/one/SKILL.md", b.Files)
+	}
+	if !strings.HasPrefix(b.Dir(), BackupsDir(root)) || !fileExists(filepath.Join(b.Dir(), backupIndexName)) {
+		t.Errorf("snapshot index missing in %s", b.Dir())
+	}
+	if !fileExists(filepath.Join(root, StateDirName, ".gitignore")) {
+		t.Error("state directory .gitignore not written")
+	}
+}
+
+func TestBackup_NothingSavedLeavesNoSnapshot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".claude")
+	b := NewBackup(root)
+	if err := b.Save(filepath.Join(root, "missing.md")); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := ListBackups(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 || b.ID != "" {
+		t.Errorf("expected no snapshot, got %+v", backups)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, ".claude")
+	config := filepath.Join(dir, "CLAUDE.md")
+	agent := filepath.Join(root, "agents", "debugger.md")
+	os.MkdirAll(filepath.Dir(agent), 0755)
+	os.WriteFile(config, []byte("my config"), 0644)
+	os.WriteFile(agent, []byte("my agent"), 0644)
+
+	b := NewBackup(root)
+	for _, p := range []string{config, agent, config} {
+		if err := b.Save(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(b.Files) != 2 || b.Files[0].Path != "../CLAUDE.md" {
+		t.Fatalf("unexpected snapshot files %+v", b.Files)
+	}
+
+	os.WriteFile(config, []byte("generated"), 0644)
+	os.Remove(agent)
+
+	preview, err := RestoreBackup(root, b.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 2 || !strings.HasPrefix(preview[0], "WOULD RESTORE:") {
+		t.Errorf("dry run results = %v", preview)
+	}
+	if readString(t, config) != "generated" {
+		t.Error("dry run modified files")
+	}
+
+	results, err := RestoreBackup(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("results = %v", results)
+	}
+	if got := readString(t, config); got != "my config" {
+		t.Errorf("CLAUDE.md = %q", got)
+	}
+	if got := readString(t, agent); got != "my agent" {
+		t.Errorf("debugger.md = %q", got)
+	}
+
+	// The overwritten config was snapshotted, so the restore can be undone.
+	backups, err := ListBackups(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || len(backups[1].Files) != 1 {
+		t.Fatalf("expected an undo snapshot with one file, got %+v", backups)
+	}
+	if _, err := RestoreBackup(root, backups[1].ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, config); got != "generated" {
+		t.Errorf("after undo CLAUDE.md = %q", got)
+	}
+}
+
+func TestRestoreBackup_Errors(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".claude")
+	if _, err := RestoreBackup(root, "", false); err == nil {
+		t.Error("expected error with no backups")
+	}
+	if _, err := RestoreBackup(root, "20200101-000000", false); err == nil {
+		t.Error("expected error for unknown backup")
+	}
+	if _, err := RestoreBackup(root, "../..", false); err == nil {
+		t.Error("expected error for path-like backup id")
+	}
+}
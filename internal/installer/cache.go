@@ -0,0 +1,548 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cache entry kinds.
+const (
+	CacheGit     = "git"
+	CacheArchive = "archive"
+)
+
+// maxSignatureSize bounds a downloaded signature file.
+const maxSignatureSize = 64 << 10
+
+// DefaultCacheFreshness is how long a cached source is used without checking
+// upstream for changes.
+const DefaultCacheFreshness = time.Hour
+
+// tempGracePeriod is how old a scratch directory must be before Prune takes
+// it for a leftover; younger ones may belong to a download in progress.
+const tempGracePeriod = time.Hour
+
+// CacheEntry describes a cached remote source.
+type CacheEntry struct {
+	Kind         string    `json:"kind"`
+	Source       string    `json:"source"`
+	Ref          string    `json:"ref,omitempty"`
+	Commit       string    `json:"commit,omitempty"`    // Commit a git ref resolved to
+	Digest       string    `json:"digest,omitempty"`    // SHA-256 of a downloaded archive
+	SignedBy     string    `json:"signed_by,omitempty"` // Key ID of a verified signature
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+	Used         time.Time `json:"used"`
+	Size         int64     `json:"-"` // Bytes on disk, filled in by Entries
+	Key          string    `json:"-"`
+}
+
+// Cache is a persistent copy of remote skill sources, kept under the user
+// cache directory so repeated installs skip the network. Git clones are
+// keyed by URL and ref, in git/<key>/. Archives are stored extracted under
+// the digest of their content, in archives/<digest>/, and each URL or local
+// archive file records which digest it last served, in urls/<key>.json, so
+// identical archives from different places are stored once.
+//
+// An entry younger than Freshness is used as is. Older entries are checked
+// upstream (git sources are cloned again, archives revalidated with their
+// ETag); when that fails, e.g. offline, the cached copy is used.
+type Cache struct {
+	Freshness time.Duration
+	Auth      *Auth         // Credentials for private sources; nil for none
+	HTTP      HTTPOptions   // Timeouts, retries and size limit for downloads
+	Extract   ExtractLimits // Bounds on what an archive may expand to
+
+	root string
+	now  func() time.Time
+}
+
+// NewCache returns the cache rooted at dir.
+func NewCache(dir string) *Cache {
+	return &Cache{Freshness: DefaultCacheFreshness, root: dir, now: time.Now}
+}
+
+// DefaultCacheDir returns where remote sources are cached: the
+// skill-installer directory in the user cache dir, or
+// $SKILL_INSTALLER_CACHE_DIR if set.
+func DefaultCacheDir() (string, error) {
+	return cacheSubdir("sources")
+}
+
+// Root returns the cache's directory.
+func (c *Cache) Root() string {
+	return c.root
+}
+
+// cacheKey names the entry for a source.
+func cacheKey(kind, source, ref string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + source + "\x00" + ref))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func (c *Cache) metaPath(kind, key string) string {
+	if kind == CacheGit {
+		return filepath.Join(c.root, "git", key+".json")
+	}
+	return filepath.Join(c.root, "urls", key+".json")
+}
+
+func (c *Cache) archiveDir(digest string) string {
+	return filepath.Join(c.root, "archives", digest)
+}
+
+// contentDir returns where an entry's files live.
+func (c *Cache) contentDir(e *CacheEntry) string {
+	if e.Kind == CacheGit {
+		return filepath.Join(c.root, "git", e.Key)
+	}
+	return c.archiveDir(e.Digest)
+}
+
+func (c *Cache) load(kind, key string) (*CacheEntry, error) {
+	data, err := os.ReadFile(c.metaPath(kind, key))
+	if err != nil {
+		return nil, err
+	}
+	var e CacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", c.metaPath(kind, key), err)
+	}
+	e.Key = key
+	return &e, nil
+}
+
+func (c *Cache) save(e *CacheEntry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := c.metaPath(e.Kind, e.Key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", filepath.Dir(p), err)
+	}
+	if err := os.WriteFile(p, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", p, err)
+	}
+	return nil
+}
+
+// lookup returns the cached entry for a source if its content is present.
+func (c *Cache) lookup(kind, source, ref string) *CacheEntry {
+	e, err := c.load(kind, cacheKey(kind, source, ref))
+	if err != nil || !dirExists(c.contentDir(e)) {
+		return nil
+	}
+	return e
+}
+
+func (c *Cache) fresh(e *CacheEntry) bool {
+	return c.now().Sub(e.Fetched) < c.Freshness
+}
+
+// use marks an entry as used and returns its content directory.
+func (c *Cache) use(e *CacheEntry) (string, error) {
+	e.Used = c.now().UTC()
+	if err := c.save(e); err != nil {
+		return "", err
+	}
+	return c.contentDir(e), nil
+}
+
+// FetchGit returns the directory holding a checkout of a git repo at ref and
+// the commit it resolved to, cloning it into the cache if needed. Entries pinned to a commit SHA never
+// change, so they are not checked upstream again.
+func (c *Cache) FetchGit(ctx context.Context, repoURL, ref string) (string, string, error) {
+	cached := c.lookup(CacheGit, repoURL, ref)
+	if cached != nil && (cached.pinned() || c.fresh(cached)) {
+		dir, err := c.use(cached)
+		return dir, cached.Commit, err
+	}
+
+	tmp, err := c.tempDir()
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(tmp)
+	clone := filepath.Join(tmp, "repo")
+	env, err := c.Auth.gitEnv(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	commit, err := checkout(ctx, repoURL, ref, clone, append(env, c.HTTP.gitLowSpeedEnv()...))
+	if err != nil {
+		if cached != nil && ctx.Err() == nil {
+			dir, useErr := c.use(cached)
+			return dir, cached.Commit, useErr
+		}
+		return "", "", err
+	}
+
+	e := &CacheEntry{
+		Kind:    CacheGit,
+		Source:  RedactURL(repoURL),
+		Ref:     ref,
+		Commit:  commit,
+		Key:     cacheKey(CacheGit, repoURL, ref),
+		Fetched: c.now().UTC(),
+	}
+	if err := c.replaceDir(clone, c.contentDir(e)); err != nil {
+		return "", "", err
+	}
+	dir, err := c.use(e)
+	return dir, commit, err
+}
+
+// pinned reports whether a git entry's ref is the commit it resolved to,
+// rather than a branch or tag.
+func (e *CacheEntry) pinned() bool {
+	return isCommitSHA(e.Ref) && strings.HasPrefix(e.Commit, strings.ToLower(e.Ref))
+}
+
+// FetchURL returns the directory holding an extracted archive (zip, tar,
+// tar.gz or tar.zst), downloading it into the cache if needed. A single
+// top-level directory wrapping the archive's content is stripped. When v is
+// set, a download is checked against its pin and trusted keys before
+// anything is extracted, and cached content that v would not accept is
+// downloaded again.
+func (c *Cache) FetchURL(ctx context.Context, url string, v *Verifier) (string, error) {
+	cached := c.lookup(CacheArchive, url, "")
+	if cached != nil && !c.accepts(v, cached) {
+		cached = nil
+	}
+	if cached != nil && c.fresh(cached) {
+		return c.use(cached)
+	}
+
+	header := make(http.Header)
+	if cached != nil {
+		if cached.ETag != "" {
+			header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := c.HTTP.get(ctx, url, c.Auth, header)
+	if err != nil {
+		if cached != nil && ctx.Err() == nil {
+			return c.use(cached)
+		}
+		return "", downloadError(url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		cached.Fetched = c.now().UTC()
+		return c.use(cached)
+	case resp.StatusCode != http.StatusOK:
+		return "", downloadError(url, fmt.Errorf("status %d", resp.StatusCode))
+	}
+
+	digest, signer, err := c.storeArchive(resp.Body, func(archive, digest string) (string, error) {
+		var sig []byte
+		if v.RequiresSignature() {
+			sig = c.downloadSignature(ctx, url)
+		}
+		return v.verifyFile(archive, digest, sig)
+	})
+	if err != nil {
+		return "", downloadError(url, err)
+	}
+	e := &CacheEntry{
+		Kind:         CacheArchive,
+		Source:       RedactURL(url),
+		Key:          cacheKey(CacheArchive, url, ""),
+		Digest:       digest,
+		SignedBy:     signer,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      c.now().UTC(),
+	}
+	return c.use(e)
+}
+
+// FetchFile returns the directory holding the extracted content of a local
+// archive file (a path or file:// URL), extracting it into the cache unless
+// the same content is already there. When v is set, the file and its
+// detached signature beside it are checked before anything is extracted.
+func (c *Cache) FetchFile(archivePath string, v *Verifier) (string, error) {
+	archivePath = strings.TrimPrefix(archivePath, "file://")
+	abs, err := filepath.Abs(archivePath)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	digest, signer, err := c.storeArchive(f, func(archive, digest string) (string, error) {
+		var sig []byte
+		if v.RequiresSignature() {
+			sig = readSignature(abs)
+		}
+		return v.verifyFile(archive, digest, sig)
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", archivePath, err)
+	}
+	return c.use(&CacheEntry{
+		Kind:     CacheArchive,
+		Source:   abs,
+		Key:      cacheKey(CacheArchive, abs, ""),
+		Digest:   digest,
+		SignedBy: signer,
+		Fetched:  c.now().UTC(),
+	})
+}
+
+// accepts reports whether cached content passes v: it matches the pinned
+// digest and was signed by a key v trusts.
+func (c *Cache) accepts(v *Verifier, e *CacheEntry) bool {
+	return v.checkDigest(e.Digest) == nil && v.trusts(e.SignedBy)
+}
+
+// downloadSignature fetches the detached signature published next to an
+// archive URL, or returns nil if there is none.
+func (c *Cache) downloadSignature(ctx context.Context, url string) []byte {
+	for _, ext := range SignatureExtensions {
+		resp, err := c.HTTP.get(ctx, url+ext, c.Auth, nil)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize))
+		resp.Body.Close()
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return data
+		}
+	}
+	return nil
+}
+
+// readSignature reads the detached signature next to a local archive, or
+// returns nil if there is none.
+func readSignature(archivePath string) []byte {
+	for _, ext := range SignatureExtensions {
+		if data, err := os.ReadFile(archivePath + ext); err == nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// storeArchive saves an archive and runs verify on it, which returns the
+// ID of the key that signed it. Only once verify passes is the archive
+// extracted under its digest, unless that content is already cached.
+func (c *Cache) storeArchive(r io.Reader, verify func(archive, digest string) (string, error)) (string, string, error) {
+	tmp, err := c.tempDir()
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, "archive")
+	f, err := os.Create(archive)
+	if err != nil {
+		return "", "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	signer, err := verify(archive, digest)
+	if err != nil {
+		return "", "", err
+	}
+	if dirExists(c.archiveDir(digest)) {
+		return digest, signer, nil
+	}
+
+	extracted := filepath.Join(tmp, "content")
+	if err := extractArchive(archive, extracted, c.Extract); err != nil {
+		return "", "", fmt.Errorf("extracting archive: %w", err)
+	}
+	content := stripTopDir(extracted)
+	if err := checkLinks(content); err != nil {
+		return "", "", fmt.Errorf("extracting archive: %w", err)
+	}
+	if err := c.replaceDir(content, c.archiveDir(digest)); err != nil {
+		return "", "", err
+	}
+	return digest, signer, nil
+}
+
+// tempDir creates a scratch directory inside the cache, so finished content
+// can be renamed into place.
+func (c *Cache) tempDir() (string, error) {
+	if err := os.MkdirAll(c.root, 0755); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", c.root, err)
+	}
+	dir, err := os.MkdirTemp(c.root, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("creating cache directory: %w", err)
+	}
+	return dir, nil
+}
+
+// replaceDir moves src to dst, replacing whatever dst held.
+func (c *Cache) replaceDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", filepath.Dir(dst), err)
+	}
+	if dirExists(dst) {
+		old, err := c.tempDir()
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(old)
+		if err := os.Rename(dst, filepath.Join(old, "old")); err != nil {
+			return fmt.Errorf("updating cache: %w", err)
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("updating cache: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the cached sources, most recently used first.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	var entries []CacheEntry
+	for _, kind := range []string{CacheGit, CacheArchive} {
+		metas, err := filepath.Glob(filepath.Join(filepath.Dir(c.metaPath(kind, "x")), "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range metas {
+			e, err := c.load(kind, strings.TrimSuffix(filepath.Base(p), ".json"))
+			if err != nil {
+				return nil, err
+			}
+			e.Size, _ = dirSize(c.contentDir(e))
+			entries = append(entries, *e)
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].Used.After(entries[b].Used) })
+	return entries, nil
+}
+
+// Prune removes sources not used within maxAge, archives no URL refers to
+// any more, and leftovers from interrupted downloads older than an hour.
+func (c *Cache) Prune(maxAge time.Duration, dryRun bool) ([]string, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	verb := "REMOVED"
+	if dryRun {
+		verb = "WOULD REMOVE"
+	}
+	var results []string
+	remove := func(label string, paths ...string) error {
+		if !dryRun {
+			for _, p := range paths {
+				if err := os.RemoveAll(p); err != nil {
+					return err
+				}
+			}
+		}
+		results = append(results, fmt.Sprintf("%s: %s", verb, label))
+		return nil
+	}
+
+	keep := make(map[string]bool)
+	for i := range entries {
+		e := &entries[i]
+		if c.now().Sub(e.Used) <= maxAge {
+			if e.Kind == CacheArchive {
+				keep[e.Digest] = true
+			}
+			continue
+		}
+		paths := []string{c.metaPath(e.Kind, e.Key)}
+		if e.Kind == CacheGit {
+			paths = append(paths, c.contentDir(e))
+		}
+		if err := remove(e.Source, paths...); err != nil {
+			return results, err
+		}
+	}
+
+	archives, _ := os.ReadDir(filepath.Join(c.root, "archives"))
+	for _, a := range archives {
+		if !keep[a.Name()] {
+			if err := remove("archive "+a.Name(), c.archiveDir(a.Name())); err != nil {
+				return results, err
+			}
+		}
+	}
+
+	temps, _ := filepath.Glob(filepath.Join(c.root, ".tmp-*"))
+	for _, p := range temps {
+		info, err := os.Stat(p)
+		if err != nil || c.now().Sub(info.ModTime()) < tempGracePeriod {
+			continue
+		}
+		if err := remove(p, p); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Clear removes the whole cache.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.root); err != nil {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	return nil
+}
+
+func dirExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// dirSize returns the total size of the files under dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
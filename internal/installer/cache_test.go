@@ -0,0 +1,306 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tarGz builds a gzipped tarball from path/content pairs.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+// testCache returns a cache with a controllable clock.
+func testCache(t *testing.T) (*Cache, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCache(filepath.Join(t.TempDir(), "cache"))
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCache_FetchURL(t *testing.T) {
+	archive := tarGz(t, map[string]string{"one/SKILL.md": "---\nname: one\n---\n"})
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	c, now := testCache(t)
+	dir, err := c.FetchURL(context.Background(), srv.URL+"/skills.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "one")) {
+		t.Fatalf("archive not extracted into %s", dir)
+	}
+
+	// A fresh entry is used without asking the server
+	if again, err := c.FetchURL(context.Background(), srv.URL+"/skills.tar.gz", nil); err != nil || again != dir || requests != 1 {
+		t.Errorf("fresh fetch = %s, %v after %d requests", again, err, requests)
+	}
+
+	// A stale entry is revalidated
+	*now = now.Add(2 * DefaultCacheFreshness)
+	if again, err := c.FetchURL(context.Background(), srv.URL+"/skills.tar.gz", nil); err != nil || again != dir || notModified != 1 {
+		t.Errorf("revalidated fetch = %s, %v (%d not modified)", again, err, notModified)
+	}
+
+	// Offline, a stale entry is still served
+	srv.Close()
+	*now = now.Add(2 * DefaultCacheFreshness)
+	if again, err := c.FetchURL(context.Background(), srv.URL+"/skills.tar.gz", nil); err != nil || again != dir {
+		t.Errorf("offline fetch = %s, %v", again, err)
+	}
+}
+
+func TestCache_FetchURLStripsTopDir(t *testing.T) {
+	archives := map[string][]byte{
+		"/wrapped.tar.gz": tarGz(t, map[string]string{
+			"skills-1.2.0/skills/one/SKILL.md": "---\nname: one\n---\n",
+			"skills-1.2.0/agents/helper.md":    "# Helper",
+		}),
+		"/single-skill.tar.gz": tarGz(t, map[string]string{"one/SKILL.md": "---\nname: one\n---\n"}),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archives[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	c, _ := testCache(t)
+	dir, err := c.FetchURL(context.Background(), srv.URL+"/wrapped.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "skills", "one")) || !fileExists(filepath.Join(dir, "agents", "helper.md")) {
+		t.Errorf("top-level directory not stripped in %s: %v", dir, listTree(t, dir))
+	}
+
+	// A lone skill directory is content, not a wrapper
+	dir, err = c.FetchURL(context.Background(), srv.URL+"/single-skill.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "one")) {
+		t.Errorf("single skill archive extracted as %v", listTree(t, dir))
+	}
+}
+
+func TestCache_IdenticalArchivesStoredOnce(t *testing.T) {
+	archive := tarGz(t, map[string]string{"one/SKILL.md": "---\nname: one\n---\n"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	c, _ := testCache(t)
+	a, err := c.FetchURL(context.Background(), srv.URL+"/a.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.FetchURL(context.Background(), srv.URL+"/b.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("identical archives cached twice: %s, %s", a, b)
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Digest != entries[1].Digest || entries[0].Size == 0 {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+// runGit runs git in dir, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(dir, append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// commitSkill writes skills/one/SKILL.md with body in repo and commits it,
+// returning the commit SHA.
+func commitSkill(t *testing.T, repo, body string) string {
+	t.Helper()
+	skill := filepath.Join(repo, "skills", "one", "SKILL.md")
+	os.MkdirAll(filepath.Dir(skill), 0755)
+	os.WriteFile(skill, []byte("---\nname: one\n---\n"+body), 0644)
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", body)
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func TestCache_FetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := filepath.Join(t.TempDir(), "repo")
+	runGit(t, "", "init", "-q", repo)
+	first := commitSkill(t, repo, "v1")
+
+	c, now := testCache(t)
+	dir, commit, err := c.FetchGit(context.Background(), repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "skills", "one")) {
+		t.Fatalf("FetchGit returned %s", dir)
+	}
+	if commit != first {
+		t.Errorf("commit = %s, want %s", commit, first)
+	}
+
+	// With the origin gone, a stale clone is still served
+	os.Rename(repo, repo+".moved")
+	*now = now.Add(2 * DefaultCacheFreshness)
+	if again, _, err := c.FetchGit(context.Background(), repo, ""); err != nil || again != dir {
+		t.Errorf("offline fetch = %s, %v", again, err)
+	}
+}
+
+func TestCache_FetchGitPinned(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := filepath.Join(t.TempDir(), "repo")
+	runGit(t, "", "init", "-q", repo)
+	first := commitSkill(t, repo, "v1")
+	runGit(t, repo, "tag", "v1.0.0")
+	runGit(t, repo, "tag", "20241015")
+	second := commitSkill(t, repo, "v2")
+	runGit(t, repo, "branch", "deadbeef")
+
+	c, _ := testCache(t)
+	tests := []struct {
+		ref        string
+		wantCommit string
+		wantBody   string
+	}{
+		{"", second, "v2"},
+		{"v1.0.0", first, "v1"},
+		{first, first, "v1"},
+		{first[:10], first, "v1"},
+		// All-hex names of a tag and a branch
+		{"20241015", first, "v1"},
+		{"deadbeef", second, "v2"},
+	}
+	for _, tt := range tests {
+		dir, commit, err := c.FetchGit(context.Background(), repo, tt.ref)
+		if err != nil {
+			t.Fatalf("ref %q: %v", tt.ref, err)
+		}
+		if commit != tt.wantCommit {
+			t.Errorf("ref %q resolved to %s, want %s", tt.ref, commit, tt.wantCommit)
+		}
+		if got := readString(t, filepath.Join(dir, "skills", "one", "SKILL.md")); !strings.HasSuffix(got, tt.wantBody) {
+			t.Errorf("ref %q: SKILL.md = %q", tt.ref, got)
+		}
+	}
+
+	// A commit SHA is never checked upstream again
+	os.RemoveAll(repo)
+	c.Freshness = 0
+	if _, commit, err := c.FetchGit(context.Background(), repo, first); err != nil || commit != first {
+		t.Errorf("pinned fetch without origin = %s, %v", commit, err)
+	}
+	if _, _, err := c.FetchGit(context.Background(), repo, "missing-tag"); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+}
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		src, url, ref string
+	}{
+		{"https://github.com/org/skills", "https://github.com/org/skills", ""},
+		{"https://github.com/org/skills@v1.4.0", "https://github.com/org/skills", "v1.4.0"},
+		{"https://github.com/org/skills#abc1234", "https://github.com/org/skills", "abc1234"},
+		{"https://user@github.com/org/skills", "https://user@github.com/org/skills", ""},
+		{"https://user@github.com/org/skills@main", "https://user@github.com/org/skills", "main"},
+		{"git@github.com:org/skills.git@release", "git@github.com:org/skills.git", "release"},
+	}
+	for _, tt := range tests {
+		url, ref := ParseGitSource(tt.src)
+		if url != tt.url || ref != tt.ref {
+			t.Errorf("ParseGitSource(%q) = %q, %q; want %q, %q", tt.src, url, ref, tt.url, tt.ref)
+		}
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	archive := tarGz(t, map[string]string{"one/SKILL.md": "---\nname: one\n---\n"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	c, now := testCache(t)
+	dir, err := c.FetchURL(context.Background(), srv.URL+"/skills.tar.gz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Mkdir(filepath.Join(c.Root(), ".tmp-interrupted"), 0755)
+	os.Chtimes(filepath.Join(c.Root(), ".tmp-interrupted"), now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	// A download in progress elsewhere
+	os.Mkdir(filepath.Join(c.Root(), ".tmp-downloading"), 0755)
+	os.Chtimes(filepath.Join(c.Root(), ".tmp-downloading"), *now, *now)
+
+	results, err := c.Prune(24*time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.Contains(results[0], ".tmp-interrupted") {
+		t.Errorf("prune of recent entries = %v", results)
+	}
+	if !dirExists(filepath.Join(c.Root(), ".tmp-downloading")) {
+		t.Error("removed a scratch directory still in use")
+	}
+
+	*now = now.Add(48 * time.Hour)
+	preview, err := c.Prune(24*time.Hour, true)
+	if err != nil || len(preview) != 3 || !strings.HasPrefix(preview[0], "WOULD REMOVE:") || !dirExists(dir) {
+		t.Errorf("dry run = %v, %v", preview, err)
+	}
+	if _, err := c.Prune(24*time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := c.Entries(); len(entries) != 0 || dirExists(dir) {
+		t.Errorf("stale entries left: %+v", entries)
+	}
+}
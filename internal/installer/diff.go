@@ -0,0 +1,273 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxDiffEdits bounds the Myers search. Past it the differing middle of the
+// inputs is reported as a single replacement, which keeps memory bounded for
+// files that were rewritten wholesale.
+const maxDiffEdits = 2000
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// diffOp is one step of an edit script turning a into b. aIdx is set for
+// equal/delete ops, bIdx for equal/insert ops.
+type diffOp struct {
+	kind opKind
+	aIdx int
+	bIdx int
+}
+
+// splitLines splits content into lines, keeping each line's trailing newline
+// so the input can be reassembled byte for byte.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// isBinary reports whether content looks like a binary file.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// diffLines returns a shortest edit script from a to b (Myers' algorithm).
+func diffLines(a, b []string) []diffOp {
+	// Trim the common prefix and suffix; they never need searching.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: opEqual, aIdx: i, bIdx: i})
+	}
+
+	middle := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, op := range middle {
+		op.aIdx += prefix
+		op.bIdx += prefix
+		ops = append(ops, op)
+	}
+
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, diffOp{kind: opEqual, aIdx: len(a) - suffix + i, bIdx: len(b) - suffix + i})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d..d] as it was before round d.
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(n, m)
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, n, m int) []diffOp {
+	var reversed []diffOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffOp{kind: opEqual, aIdx: x, bIdx: y})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, diffOp{kind: opInsert, aIdx: x, bIdx: y})
+		} else {
+			x--
+			reversed = append(reversed, diffOp{kind: opDelete, aIdx: x, bIdx: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, diffOp{kind: opEqual, aIdx: x, bIdx: y})
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for idx, op := range reversed {
+		ops[len(reversed)-1-idx] = op
+	}
+	return ops
+}
+
+// replaceAll is the fallback edit script: delete all of a, insert all of b.
+func replaceAll(n, m int) []diffOp {
+	ops := make([]diffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffOp{kind: opDelete, aIdx: i})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, diffOp{kind: opInsert, aIdx: n, bIdx: j})
+	}
+	return ops
+}
+
+// UnifiedDiff renders a unified diff between a and b with three lines of
+// context. It returns an empty string when the inputs are identical.
+func UnifiedDiff(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	if isBinary(a) || isBinary(b) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", aName, bName)
+	}
+
+	const context = 3
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+
+		// Extend the hunk until a run of more than 2*context equal lines
+		hunkStart := start - context
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+		hunkEnd := end + context
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		writeHunk(&out, ops[hunkStart:hunkEnd], aLines, bLines)
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp, aLines, bLines []string) {
+	aStart, bStart := ops[0].aIdx, ops[0].bIdx
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+
+	for _, op := range ops {
+		var prefix, line string
+		switch op.kind {
+		case opEqual:
+			prefix, line = " ", aLines[op.aIdx]
+		case opDelete:
+			prefix, line = "-", aLines[op.aIdx]
+		case opInsert:
+			prefix, line = "+", bLines[op.bIdx]
+		}
+		out.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
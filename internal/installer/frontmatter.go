@@ -0,0 +1,248 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// errNoFrontmatter is reported for a SKILL.md that does not open with ---.
+var errNoFrontmatter = errors.New("no frontmatter: SKILL.md must start with a --- block")
+
+// yamlLine finds the line yaml.v3 puts in its syntax errors.
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// lineError is a frontmatter problem on a line of the file.
+type lineError struct {
+	line int
+	msg  string
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// splitFrontmatter returns the YAML between the first --- line and the next
+// one, and the line of the file it starts on.
+func splitFrontmatter(content []byte) (string, int, error) {
+	lines := strings.Split(string(content), "\n")
+	start := -1
+	for n, line := range lines {
+		if strings.TrimSpace(line) != "---" {
+			continue
+		}
+		if start < 0 {
+			start = n + 1
+			continue
+		}
+		return strings.Join(lines[start:n], "\n"), start + 1, nil
+	}
+	if start < 0 {
+		return "", 0, errNoFrontmatter
+	}
+	return "", 0, &lineError{start, "frontmatter is not closed with ---"}
+}
+
+// parseFrontmatter parses the frontmatter of a markdown file. It returns the
+// mapping of its fields, or nil when it is empty, and the line of the file
+// the frontmatter starts on.
+func parseFrontmatter(content []byte) (*yaml.Node, int, error) {
+	fm, first, err := splitFrontmatter(content)
+	if err != nil {
+		return nil, 0, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(fm), &doc); err != nil {
+		return nil, 0, yamlError(err, first)
+	}
+	if len(doc.Content) == 0 {
+		return nil, first, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, 0, &lineError{root.Line + first - 1, "frontmatter must be a set of key: value fields"}
+	}
+	return root, first, nil
+}
+
+// parseSkill extracts skill metadata from frontmatter. Errors name the line
+// of the file they were found on.
+func parseSkill(content []byte) (Skill, error) {
+	skill := Skill{Content: content}
+	root, first, err := parseFrontmatter(content)
+	if err != nil {
+		return skill, err
+	}
+	if root != nil {
+		if err := skill.setFields(root, first); err != nil {
+			return skill, err
+		}
+	}
+
+	if skill.Name == "" {
+		return skill, fmt.Errorf("skill missing name in frontmatter")
+	}
+	return skill, nil
+}
+
+// setFields fills in the skill from the frontmatter's fields. Fields it does
+// not know are kept in Extra.
+func (s *Skill) setFields(root *yaml.Node, first int) error {
+	seen := make(map[string]int)
+	for n := 0; n+1 < len(root.Content); n += 2 {
+		key, value := root.Content[n], root.Content[n+1]
+		line := key.Line + first - 1
+		if prev, ok := seen[key.Value]; ok {
+			return &lineError{line, fmt.Sprintf("%s is already set on line %d", key.Value, prev)}
+		}
+		seen[key.Value] = line
+
+		var err error
+		switch key.Value {
+		case "name":
+			s.Name, err = scalarField(value)
+		case "description":
+			s.Description, err = scalarField(value)
+		case "model":
+			s.Model, err = scalarField(value)
+		case "version":
+			s.Version, err = scalarField(value)
+		case "license":
+			s.License, err = scalarField(value)
+		case "argument-hint":
+			s.ArgumentHint, err = argumentHint(value)
+		case "allowed-tools":
+			s.AllowedTools, err = listField(value)
+		case "tags":
+			s.Tags, err = listField(value)
+		case "languages":
+			s.Languages, err = listField(value)
+		case "requires":
+			s.Requires, err = listField(value)
+		default:
+			var v any
+			if err = value.Decode(&v); err == nil {
+				if s.Extra == nil {
+					s.Extra = make(map[string]any)
+				}
+				s.Extra[key.Value] = v
+			}
+		}
+		if err != nil {
+			return &lineError{line, key.Value + " " + err.Error()}
+		}
+	}
+	return nil
+}
+
+// fieldLines returns the line of the file each frontmatter field is on.
+func fieldLines(root *yaml.Node, first int) map[string]int {
+	lines := make(map[string]int)
+	if root == nil {
+		return lines
+	}
+	for n := 0; n+1 < len(root.Content); n += 2 {
+		lines[root.Content[n].Value] = root.Content[n].Line + first - 1
+	}
+	return lines
+}
+
+// scalarField returns a single value, with folded and literal blocks
+// trimmed of their final newline.
+func scalarField(n *yaml.Node) (string, error) {
+	if n.Kind != yaml.ScalarNode {
+		return "", errors.New("must be a single value")
+	}
+	if n.Tag == "!!null" {
+		return "", nil
+	}
+	return strings.TrimSpace(n.Value), nil
+}
+
+// argumentHint returns an argument hint, which is commonly written as a
+// flow list such as [project-name] but means the text as written.
+func argumentHint(n *yaml.Node) (string, error) {
+	if n.Kind == yaml.SequenceNode && n.Style == yaml.FlowStyle {
+		out, err := yaml.Marshal(n)
+		return strings.TrimSpace(string(out)), err
+	}
+	return scalarField(n)
+}
+
+// listField returns a list given as a YAML sequence of values, or as a
+// single comma-separated value.
+func listField(n *yaml.Node) ([]string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil, nil
+		}
+		return splitList(n.Value), nil
+	case yaml.SequenceNode:
+		var items []string
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, errors.New("must be a list of single values")
+			}
+			if v := strings.TrimSpace(item.Value); v != "" {
+				items = append(items, v)
+			}
+		}
+		return items, nil
+	}
+	return nil, errors.New("must be a list")
+}
+
+// splitList splits a comma-separated value, leaving commas inside
+// parentheses alone so "Bash(git add:*, git commit:*)" stays one item.
+func splitList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	add := func(item string) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	for n, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				add(s[start:n])
+				start = n + 1
+			}
+		}
+	}
+	add(s[start:])
+	return items
+}
+
+// yamlString returns s as a YAML value, quoted when it would otherwise be
+// read as something else, e.g. when it holds ": ".
+func yamlString(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// yamlError rewrites a yaml.v3 error to count lines from the top of the
+// file rather than the frontmatter.
+func yamlError(err error, first int) error {
+	msg := err.Error()
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return &lineError{n + first - 1, msg[len(m[0]):]}
+	}
+	return fmt.Errorf("frontmatter: %s", strings.TrimPrefix(msg, "yaml: "))
+}
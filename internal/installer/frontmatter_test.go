@@ -0,0 +1,109 @@
+package installer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSkill_YAML(t *testing.T) {
+	content := []byte(`---
+name: reviewer
+description: >
+  Reviews code: style,
+  correctness and tests.
+version: 1.10
+license: 'MIT'
+model: "opus"
+tags:
+  - review
+  - quality
+allowed-tools: Read, Grep, Bash(git diff:*, git log:*)
+argument-hint: [pr-number]
+metadata:
+  owner: platform
+  reviewed: true
+---
+# Reviewer`)
+	skill, err := parseSkill(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Skill{
+		Name:         "reviewer",
+		Description:  "Reviews code: style, correctness and tests.",
+		Model:        "opus",
+		Version:      "1.10",
+		License:      "MIT",
+		ArgumentHint: "[pr-number]",
+		AllowedTools: []string{"Read", "Grep", "Bash(git diff:*, git log:*)"},
+		Tags:         []string{"review", "quality"},
+		Extra:        map[string]any{"metadata": map[string]any{"owner": "platform", "reviewed": true}},
+		Content:      content,
+	}
+	if !reflect.DeepEqual(skill, want) {
+		t.Errorf("parseSkill =\n%+v\nwant\n%+v", skill, want)
+	}
+}
+
+func TestParseSkill_QuotedDescription(t *testing.T) {
+	content := []byte("---\nname: brainstorming\ndescription: 'You MUST use this - it''s first: always.'\n---\n")
+	skill, err := parseSkill(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "You MUST use this - it's first: always."; skill.Description != want {
+		t.Errorf("got description %q, want %q", skill.Description, want)
+	}
+}
+
+func TestParseSkill_ErrorLines(t *testing.T) {
+	tests := []struct {
+		content string
+		wantErr string
+	}{
+		{"---\nname: x\ndescription: uses: colons\n---\n", "line 3: mapping values are not allowed"},
+		{"\n---\nname: x\ntags: {a: 1}\n---\n", "line 4: tags must be a list"},
+		{"---\nname: x\ndescription: a\nname: y\n---\n", "line 4: name is already set on line 2"},
+		{"---\nname: [x\n---\n", "line 2: did not find expected ',' or ']'"},
+		{"---\nname: x\n", "line 1: frontmatter is not closed with ---"},
+		{"---\n- a\n- b\n---\n", "line 2: frontmatter must be a set of key: value fields"},
+	}
+	for _, tt := range tests {
+		_, err := parseSkill([]byte(tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseSkill(%q) = %v, want %q", tt.content, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateSkillTemplate_Parses(t *testing.T) {
+	content := GenerateSkillTemplate("my-skill", "Use when: things break", "opus", []string{"custom"}, nil)
+	skill, err := parseSkill([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skill.Description != "Use when: things break" {
+		t.Errorf("got description %q", skill.Description)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"a, b, c", 3},
+		{"single", 1},
+		{"", 0},
+		{"  spaced , items  ,", 2},
+		{"Read, Bash(git add:*, git commit:*)", 2},
+	}
+
+	for _, tt := range tests {
+		got := splitList(tt.input)
+		if len(got) != tt.want {
+			t.Errorf("splitList(%q) returned %d items, want %d", tt.input, len(got), tt.want)
+		}
+	}
+}
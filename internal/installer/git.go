@@ -0,0 +1,121 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ParseGitSource splits a git source into the repository URL and the ref to
+// check out. A ref is given after '#' (https://host/org/repo#<sha>) or after
+// '@' in the last path segment (https://host/org/repo@v1.4.0); without one,
+// the default branch is used.
+func ParseGitSource(src string) (repoURL, ref string) {
+	if i := strings.LastIndex(src, "#"); i >= 0 {
+		return src[:i], src[i+1:]
+	}
+	slash := strings.LastIndex(src, "/")
+	if slash < 0 {
+		return src, ""
+	}
+	if at := strings.LastIndex(src[slash:], "@"); at >= 0 {
+		return src[:slash+at], src[slash+at+1:]
+	}
+	return src, ""
+}
+
+// isCommitSHA reports whether ref looks like an (abbreviated) commit SHA.
+// Branch and tag names can look like one as well.
+func isCommitSHA(ref string) bool {
+	if len(ref) < 7 || len(ref) > 40 {
+		return false
+	}
+	for _, r := range ref {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkout makes a shallow clone of repoURL at ref (a branch, tag, or commit
+// SHA; empty for the default branch) in dir and returns the commit it
+// resolved to. A ref that looks like a SHA is still taken for a branch or
+// tag when the repo has one of that name. env is added to git's
+// environment, e.g. for credentials. Cancelling ctx stops git.
+func checkout(ctx context.Context, repoURL, ref, dir string, env []string) (string, error) {
+	git := func(dir string, args ...string) (string, error) {
+		return gitContext(ctx, env, dir, args...)
+	}
+
+	// Branches and tags can be all hex too, so they take precedence
+	pinned := isCommitSHA(ref)
+	if pinned {
+		refs, err := git("", "ls-remote", repoURL, "refs/heads/"+ref, "refs/tags/"+ref)
+		pinned = err != nil || refs == ""
+	}
+
+	switch {
+	case !pinned:
+		args := []string{"clone", "--depth", "1"}
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+		if _, err := git("", append(args, repoURL, dir)...); err != nil {
+			return "", fmt.Errorf("cloning repo: %w", err)
+		}
+	default:
+		// Fetch just the commit; servers that refuse to serve a bare SHA
+		// (or an abbreviated one) need a full clone instead.
+		_, err := git("", "init", "-q", dir)
+		if err == nil {
+			_, err = git(dir, "fetch", "-q", "--depth", "1", repoURL, ref)
+		}
+		if err == nil {
+			_, err = git(dir, "checkout", "-q", "FETCH_HEAD")
+		} else {
+			os.RemoveAll(dir)
+			if _, err = git("", "clone", "-q", "--no-checkout", repoURL, dir); err == nil {
+				_, err = git(dir, "checkout", "-q", ref)
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("checking out %s: %w", ref, err)
+		}
+	}
+
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("resolving commit: %w", err)
+	}
+	if pinned && !strings.HasPrefix(commit, strings.ToLower(ref)) {
+		return "", fmt.Errorf("checking out %s: got commit %s", ref, commit)
+	}
+	return commit, nil
+}
+
+// git runs a git command, in dir if set, and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	return gitContext(context.Background(), nil, dir, args...)
+}
+
+// gitContext runs git with env added to its environment, stopping it when
+// ctx is cancelled. URLs in its error output are redacted, since they may
+// carry credentials.
+func gitContext(ctx context.Context, env []string, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %w", args[0], redactText(strings.TrimSpace(string(output))), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,275 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Download defaults.
+const (
+	DefaultHTTPTimeout = 30 * time.Second
+	DefaultHTTPRetries = 3
+	DefaultMaxDownload = 512 << 20
+)
+
+// progressThreshold is the size from which downloads report progress.
+const progressThreshold = 4 << 20
+
+// retryBackoff is the wait before the first retry; it doubles for each
+// further one. It is a variable so tests can shorten it.
+var retryBackoff = 500 * time.Millisecond
+
+// errStalled is reported when a download receives nothing for a timeout.
+var errStalled = errors.New("download stalled")
+
+// HTTPOptions controls how sources and registry indexes are downloaded.
+// Zero fields take the defaults.
+type HTTPOptions struct {
+	Timeout  time.Duration          // Limit on connecting, on waiting for a response, and on any stall while reading one
+	Retries  int                    // Further attempts after a 5xx response or a dropped connection; negative for none
+	MaxSize  int64                  // Largest accepted download, in bytes
+	Progress func(DownloadProgress) // Called as large downloads proceed; nil for no progress
+}
+
+// DownloadProgress reports how far a download has got.
+type DownloadProgress struct {
+	URL   string // Redacted
+	Read  int64
+	Total int64 // -1 if the server did not say
+	Done  bool  // Set on the last call, when the download ended
+}
+
+func (o HTTPOptions) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultHTTPTimeout
+	}
+	return o.Timeout
+}
+
+func (o HTTPOptions) retries() int {
+	switch {
+	case o.Retries < 0:
+		return 0
+	case o.Retries == 0:
+		return DefaultHTTPRetries
+	}
+	return o.Retries
+}
+
+func (o HTTPOptions) maxSize() int64 {
+	if o.MaxSize <= 0 {
+		return DefaultMaxDownload
+	}
+	return o.MaxSize
+}
+
+// client returns an HTTP client that goes through the proxy named by
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY. There is no overall time limit,
+// as large archives may take long; stalls are caught by the body reader.
+func (o HTTPOptions) client() *http.Client {
+	timeout := o.timeout()
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// get downloads rawURL with the credentials auth has for its host and the
+// given extra headers. Failed connections and 5xx responses are retried
+// with exponential backoff. Responses of any other status are returned as
+// is; the body of a 200 is limited to MaxSize, fails when it stalls for
+// longer than Timeout, and reports progress. Callers close the body.
+func (o HTTPOptions) get(ctx context.Context, rawURL string, auth *Auth, header http.Header) (*http.Response, error) {
+	client := o.client()
+	var lastErr error
+	for attempt := 0; attempt <= o.retries(); attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryBackoff << (attempt - 1)):
+			}
+		}
+
+		reqCtx, cancel := context.WithCancelCause(ctx)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			cancel(nil)
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if err := auth.authorize(req); err != nil {
+			cancel(nil)
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		switch {
+		case err != nil:
+			cancel(nil)
+			if ctx.Err() != nil || !retryable(err) {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		case resp.StatusCode >= 500:
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			cancel(nil)
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if resp.ContentLength > o.maxSize() {
+			resp.Body.Close()
+			cancel(nil)
+			return nil, fmt.Errorf("%s exceeds the %s download limit", FormatSize(resp.ContentLength), FormatSize(o.maxSize()))
+		}
+		resp.Body = o.guard(reqCtx, cancel, resp, RedactURL(rawURL))
+		return resp, nil
+	}
+	if o.retries() > 0 {
+		return nil, fmt.Errorf("%w (after %d attempts)", lastErr, o.retries()+1)
+	}
+	return nil, lastErr
+}
+
+// retryable reports whether a failed request may succeed when retried: the
+// connection was reset or dropped, or timed out. Refused connections and
+// unknown hosts are not retried, as they usually mean being offline.
+func retryable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// guard wraps a response body with the stall timeout, the size limit and
+// progress reporting.
+func (o HTTPOptions) guard(ctx context.Context, cancel context.CancelCauseFunc, resp *http.Response, label string) io.ReadCloser {
+	timeout := o.timeout()
+	b := &guardedBody{
+		body:    resp.Body,
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: timeout,
+		timer:   time.AfterFunc(timeout, func() { cancel(errStalled) }),
+		limit:   o.maxSize(),
+		total:   resp.ContentLength,
+		url:     label,
+	}
+	if o.Progress != nil && (resp.ContentLength < 0 || resp.ContentLength >= progressThreshold) {
+		b.progress = o.Progress
+	}
+	return b
+}
+
+type guardedBody struct {
+	body    io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+	timer   *time.Timer
+	limit   int64
+
+	url      string
+	total    int64
+	read     int64
+	progress func(DownloadProgress)
+	reported time.Time
+	shown    bool
+	done     bool
+}
+
+func (b *guardedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.timer.Reset(b.timeout)
+	b.read += int64(n)
+	if b.read > b.limit {
+		err = fmt.Errorf("download exceeds the %s limit", FormatSize(b.limit))
+	} else if err != nil && err != io.EOF {
+		if cause := context.Cause(b.ctx); cause != nil {
+			err = cause
+		}
+	}
+	b.report(err != nil)
+	return n, err
+}
+
+// report passes progress on, at most a few times a second. Unknown-length
+// downloads only report once they have passed the threshold.
+func (b *guardedBody) report(end bool) {
+	if b.progress == nil || b.done {
+		return
+	}
+	if !b.shown && b.total < 0 && b.read < progressThreshold {
+		return
+	}
+	if !end && time.Since(b.reported) < 200*time.Millisecond {
+		return
+	}
+	b.progress(DownloadProgress{URL: b.url, Read: b.read, Total: b.total, Done: end})
+	b.reported, b.shown, b.done = time.Now(), true, end
+}
+
+func (b *guardedBody) Close() error {
+	b.timer.Stop()
+	if b.shown {
+		b.report(true)
+	}
+	err := b.body.Close()
+	b.cancel(nil)
+	return err
+}
+
+// cancelBody releases a request's context when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelCauseFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel(nil)
+	return err
+}
+
+// FormatSize renders a byte count for humans, e.g. "1.5 KB".
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// gitLowSpeedEnv makes git abort transfers that stall for the timeout.
+func (o HTTPOptions) gitLowSpeedEnv() []string {
+	return []string{
+		"GIT_HTTP_LOW_SPEED_LIMIT=1",
+		"GIT_HTTP_LOW_SPEED_TIME=" + strconv.Itoa(int(o.timeout().Seconds())),
+	}
+}
@@ -0,0 +1,163 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPOptions_Retries(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/flaky" && requests < 3:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/reset" && requests < 2:
+			// Drop the connection without a response
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+		case r.URL.Path == "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		opts     HTTPOptions
+		status   int
+		requests int
+		wantErr  string
+	}{
+		{"/flaky", HTTPOptions{}, http.StatusOK, 3, ""},
+		{"/reset", HTTPOptions{}, http.StatusOK, 2, ""},
+		{"/missing", HTTPOptions{}, http.StatusNotFound, 1, ""},
+		{"/down", HTTPOptions{Retries: 2}, 0, 3, "status 503 (after 3 attempts)"},
+		{"/down", HTTPOptions{Retries: -1}, 0, 1, "status 503"},
+	}
+	for _, tt := range tests {
+		requests = 0
+		resp, err := tt.opts.get(context.Background(), srv.URL+tt.path, nil, nil)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		switch {
+		case tt.wantErr != "":
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: err = %v, want %q", tt.path, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.path, err)
+		case resp.StatusCode != tt.status:
+			t.Errorf("%s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if requests != tt.requests {
+			t.Errorf("%s: %d requests, want %d", tt.path, requests, tt.requests)
+		}
+	}
+}
+
+func TestHTTPOptions_Limits(t *testing.T) {
+	big := strings.Repeat("x", progressThreshold+1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chunked":
+			// No Content-Length, so the limit applies while reading
+			w.Write([]byte(big[:1024]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(big[1024:]))
+		case "/stall":
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.Header().Set("Content-Length", strconv.Itoa(len(big)))
+			io.WriteString(w, big)
+		}
+	}))
+	defer srv.Close()
+
+	read := func(opts HTTPOptions, path string) error {
+		resp, err := opts.get(context.Background(), srv.URL+path, nil, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	if err := read(HTTPOptions{MaxSize: 1 << 20}, "/big"); err == nil || !strings.Contains(err.Error(), "exceeds the 1.0 MB download limit") {
+		t.Errorf("declared size over the limit: %v", err)
+	}
+	if err := read(HTTPOptions{MaxSize: 1 << 20}, "/chunked"); err == nil || !strings.Contains(err.Error(), "exceeds the 1.0 MB limit") {
+		t.Errorf("streamed size over the limit: %v", err)
+	}
+	if err := read(HTTPOptions{Timeout: 50 * time.Millisecond}, "/stall"); !errors.Is(err, errStalled) {
+		t.Errorf("stalled download: %v", err)
+	}
+
+	var reports []DownloadProgress
+	opts := HTTPOptions{Progress: func(p DownloadProgress) { reports = append(reports, p) }}
+	if err := read(opts, "/big?token=secret"); err != nil {
+		t.Fatal(err)
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Read != int64(len(big)) || last.Total != int64(len(big)) {
+		t.Errorf("last progress = %+v", last)
+	}
+	if strings.Contains(last.URL, "secret") {
+		t.Errorf("progress shows %s", last.URL)
+	}
+}
+
+func TestHTTPOptions_Cancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := HTTPOptions{}.get(ctx, srv.URL, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled download: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("cancellation did not stop the download")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,14 +1,9 @@
 package installer
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"fmt"
-	"io"
 	"io/fs"
-	"net/http"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -16,14 +11,20 @@ import (
 
 // Skill represents a skill definition parsed from frontmatter.
 type Skill struct {
-	Name        string
-	Description string
-	Model       string
-	Tags        []string
-	Languages   []string
-	DirPath     string // Directory path within embedded FS (e.g., "skills/systematic-debugging")
-	FilePath    string // SKILL.md path within embedded FS
-	Content     []byte // Content of SKILL.md
+	Name         string
+	Description  string
+	Model        string
+	Version      string
+	License      string
+	ArgumentHint string   // argument-hint: shown when the skill is invoked
+	AllowedTools []string // allowed-tools: the tools the skill may use without asking
+	Tags         []string
+	Languages    []string
+	Requires     []string       // requires: the skills and agents installed along with this one
+	Extra        map[string]any // Remaining frontmatter fields, by name
+	DirPath      string         // Directory path within embedded FS (e.g., "skills/systematic-debugging")
+	FilePath     string         // SKILL.md path within embedded FS
+	Content      []byte         // Content of SKILL.md
 }
 
 // Options configures the installer behavior.
@@ -32,21 +33,47 @@ type Options struct {
 	DryRun bool // Don't actually write files
 }
 
+// OverwriteChoice is what to do with an existing file that differs from the
+// one being installed.
+type OverwriteChoice int
+
+const (
+	// OverwriteKeep leaves the existing file untouched.
+	OverwriteKeep OverwriteChoice = iota
+	// OverwriteReplace replaces the existing file.
+	OverwriteReplace
+	// OverwriteKeepBoth keeps the existing file and writes the new version
+	// next to it with a .new suffix.
+	OverwriteKeepBoth
+)
+
+// OverwriteFunc decides, file by file, what happens to existing files that
+// differ from the ones being installed. It is not consulted with Force.
+type OverwriteFunc func(filePath string, existing, incoming []byte) (OverwriteChoice, error)
+
 // AgentNameFunc transforms an agent filename for the target framework.
 // Pass nil to keep original names.
 type AgentNameFunc func(originalName string) string
 
 // Installer handles installing skills, agents, and commands.
 type Installer struct {
-	fsys    fs.FS
-	options Options
+	fsys          fs.FS
+	options       Options
+	manifest      *Manifest
+	txn           *Transaction
+	backup        *Backup
+	overwrite     OverwriteFunc
+	agentsFS      fs.FS        // Where the agents that go with the skills are read from; nil for the skills' own content
+	installAgents bool         // Whether those agents are installed, so their requires: apply
+	deps          []Dependency // Skills the last install pulled in as dependencies
 }
 
 // New creates a new Installer with the given filesystem and options.
 func New(fsys fs.FS, opts Options) *Installer {
 	return &Installer{
-		fsys:    fsys,
-		options: opts,
+		fsys:          fsys,
+		options:       opts,
+		installAgents: true,
 	}
 }
 
@@ -55,11 +82,71 @@ func (i *Installer) HasForce() bool {
 	return i.options.Force
 }
 
+// SetManifest makes the installer record every file it owns in m.
+// Pass nil to stop tracking.
+func (i *Installer) SetManifest(m *Manifest) {
+	i.manifest = m
+}
+
+// SetOverwriteFunc makes the installer ask f about every existing file that
+// differs from the one being installed, instead of skipping it. Pass nil to
+// skip such files.
+func (i *Installer) SetOverwriteFunc(f OverwriteFunc) {
+	i.overwrite = f
+}
+
+// SetBackup makes the installer snapshot every file into b before
+// overwriting it. Pass nil to overwrite without a backup.
+func (i *Installer) SetBackup(b *Backup) {
+	i.backup = b
+}
+
+// Backup returns the snapshot set with SetBackup, or nil.
+func (i *Installer) Backup() *Backup {
+	return i.backup
+}
+
+// SetAgents names the content whose agents/ directory goes with the
+// skills, so that skills may require those agents. It must be the source
+// the skills come from, as requires: only resolves within one source. When
+// install is set the agents are being installed too, and the skills they
+// require are added. It defaults to the agents/ next to the skills in the
+// installer's content (none, for InstallFromLocal), installed.
+func (i *Installer) SetAgents(fsys fs.FS, install bool) {
+	i.agentsFS, i.installAgents = fsys, install
+}
+
+// Dependencies returns the skills the last install pulled in only because
+// others required them.
+func (i *Installer) Dependencies() []Dependency {
+	return i.deps
+}
+
+// WithFS returns an installer for the content in fsys that shares i's
+// options, manifest, transaction, backup, and overwrite prompt.
+func (i *Installer) WithFS(fsys fs.FS) *Installer {
+	c := *i
+	c.fsys = fsys
+	return &c
+}
+
+// SetTransaction makes the installer stage its writes in tx instead of
+// writing files directly; nothing lands until tx is committed.
+// Pass nil to write directly.
+func (i *Installer) SetTransaction(tx *Transaction) {
+	i.txn = tx
+}
+
 // discoverSkills walks the skills/ directory finding directories that contain SKILL.md.
 func (i *Installer) discoverSkills() ([]Skill, error) {
+	return i.discoverSkillsIn("skills")
+}
+
+// discoverSkillsIn finds the skill directories directly under root.
+func (i *Installer) discoverSkillsIn(root string) ([]Skill, error) {
 	var skills []Skill
 
-	entries, err := fs.ReadDir(i.fsys, "skills")
+	entries, err := fs.ReadDir(i.fsys, root)
 	if err != nil {
 		return nil, fmt.Errorf("reading skills directory: %w", err)
 	}
@@ -69,7 +156,7 @@ func (i *Installer) discoverSkills() ([]Skill, error) {
 			continue
 		}
 
-		dirPath := path.Join("skills", entry.Name())
+		dirPath := path.Join(root, entry.Name())
 		skill, ok := i.tryParseSkillDir(dirPath)
 		if ok {
 			skills = append(skills, skill)
@@ -115,9 +202,21 @@ func (i *Installer) tryParseSkillDir(dirPath string) (Skill, bool) {
 func (i *Installer) listDirFiles(dirPath string) ([]string, error) {
 	var files []string
 	err := fs.WalkDir(i.fsys, dirPath, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil {
 			return err
 		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Linked files are copied; linked directories and dangling links are not
+			if info, err := fs.Stat(i.fsys, p); err != nil || info.IsDir() {
+				return nil
+			}
+		}
 		files = append(files, p)
 		return nil
 	})
@@ -125,42 +224,92 @@ func (i *Installer) listDirFiles(dirPath string) ([]string, error) {
 }
 
 // InstallSkills copies entire skill directories to destDir, optionally filtered by tags/languages.
+// Skills the installed ones require are installed too; see Dependencies.
 func (i *Installer) InstallSkills(destDir string, tags, languages []string) ([]string, error) {
-	var results []string
-
 	skills, err := i.discoverSkills()
 	if err != nil {
 		return nil, err
 	}
+	return i.installSkills(skills, destDir, tags, languages)
+}
 
-	for _, skill := range skills {
-		// Apply tag/language filter if provided
-		if len(tags) > 0 || len(languages) > 0 {
-			if !matchesFilter(skill, tags, languages) {
-				continue
-			}
+// installSkills installs those of skills that match tags and languages,
+// and the skills they require.
+func (i *Installer) installSkills(skills []Skill, destDir string, tags, languages []string) ([]string, error) {
+	selected, err := i.selectSkills(skills, tags, languages)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, skill := range selected {
+		skillResults, err := i.installSkill(skill, destDir)
+		if err != nil {
+			return nil, err
 		}
+		results = append(results, skillResults...)
+	}
 
-		// List all files in this skill's directory
-		files, err := i.listDirFiles(skill.DirPath)
-		if err != nil {
-			return nil, fmt.Errorf("listing files in %s: %w", skill.DirPath, err)
+	return results, nil
+}
+
+// selectSkills returns those of skills that match tags and languages,
+// followed by the skills they and the agents require, which it records as
+// the install's dependencies.
+func (i *Installer) selectSkills(skills []Skill, tags, languages []string) ([]Skill, error) {
+	var selected []Skill
+	for _, skill := range skills {
+		if matchesFilter(skill, tags, languages) {
+			selected = append(selected, skill)
 		}
+	}
+	agentsFS := i.agentsFS
+	if agentsFS == nil {
+		agentsFS = i.fsys
+	}
+	selected, deps, err := resolveRequires(skills, selected, discoverAgents(agentsFS), i.installAgents)
+	if err != nil {
+		return nil, err
+	}
+	i.deps = deps
+	return selected, nil
+}
 
-		for _, file := range files {
-			// Compute relative path from "skills/" prefix
-			relPath := strings.TrimPrefix(file, "skills/")
-			targetPath := filepath.Join(destDir, relPath)
+// installSkill copies a skill's directory to destDir/<directory name>.
+func (i *Installer) installSkill(skill Skill, destDir string) ([]string, error) {
+	var results []string
+	skillName := path.Base(skill.DirPath)
 
-			fileContent, err := fs.ReadFile(i.fsys, file)
-			if err != nil {
-				return nil, fmt.Errorf("reading %s: %w", file, err)
-			}
+	result, skip, err := i.unlinkSkillDir(filepath.Join(destDir, skillName))
+	if err != nil {
+		return nil, err
+	}
+	if result != "" {
+		results = append(results, result)
+	}
+	if skip {
+		return results, nil
+	}
 
-			result, err := i.writeFile(targetPath, fileContent)
-			if err != nil {
-				return nil, err
-			}
+	// List all files in this skill's directory
+	files, err := i.listDirFiles(skill.DirPath)
+	if err != nil {
+		return nil, fmt.Errorf("listing files in %s: %w", skill.DirPath, err)
+	}
+
+	for _, file := range files {
+		relPath := strings.TrimPrefix(strings.TrimPrefix(file, skill.DirPath), "/")
+		targetPath := filepath.Join(destDir, skillName, filepath.FromSlash(relPath))
+
+		fileContent, err := fs.ReadFile(i.fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		result, err := i.installFile(KindSkill, skillName, targetPath, fileContent)
+		if err != nil {
+			return nil, err
+		}
+		if result != "" {
 			results = append(results, result)
 		}
 	}
@@ -193,11 +342,13 @@ func (i *Installer) InstallAgents(destDir string, nameFunc AgentNameFunc) ([]str
 		}
 
 		targetPath := filepath.Join(destDir, destName)
-		result, err := i.writeFile(targetPath, content)
+		result, err := i.installFile(KindAgent, destName, targetPath, content)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, result)
+		if result != "" {
+			results = append(results, result)
+		}
 	}
 
 	return results, nil
@@ -226,11 +377,13 @@ func (i *Installer) InstallCommands(destDir string) ([]string, error) {
 			return nil, fmt.Errorf("reading %s: %w", file, err)
 		}
 
-		result, err := i.writeFile(targetPath, content)
+		result, err := i.installFile(KindCommand, strings.SplitN(relPath, "/", 2)[0], targetPath, content)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, result)
+		if result != "" {
+			results = append(results, result)
+		}
 	}
 
 	return results, nil
@@ -246,133 +399,71 @@ func (i *Installer) ListAllSkills() ([]Skill, error) {
 	return i.discoverSkills()
 }
 
-// InstallFromLocal installs skills from a local directory (copies all files preserving structure).
-func (i *Installer) InstallFromLocal(srcDir, destDir string) ([]string, error) {
-	var results []string
-
-	err := filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
-			return err
-		}
-
-		content, err := os.ReadFile(p)
-		if err != nil {
-			return fmt.Errorf("reading %s: %w", p, err)
-		}
-
-		relPath, _ := filepath.Rel(srcDir, p)
-		targetPath := filepath.Join(destDir, relPath)
-
-		result, err := i.writeFile(targetPath, content)
-		if err != nil {
-			return err
-		}
-		results = append(results, result)
-		return nil
-	})
-
-	return results, err
-}
-
-// InstallFromGit clones a git repo and installs skills from it.
-func (i *Installer) InstallFromGit(repoURL, destDir string) ([]string, error) {
-	tmpDir, err := os.MkdirTemp("", "skill-installer-*")
+// InstallFromLocal installs the skills in srcDir, a directory laid out like
+// the embedded skills/: each subdirectory with a SKILL.md is a skill, and
+// anything else (READMEs, tests, .git) is left behind. Skills are filtered
+// by tags and languages like the embedded ones.
+func (i *Installer) InstallFromLocal(srcDir, destDir string, tags, languages []string) ([]string, error) {
+	local := i.WithFS(os.DirFS(srcDir))
+	skills, err := local.discoverSkillsIn(".")
 	if err != nil {
-		return nil, fmt.Errorf("creating temp dir: %w", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	cmd := exec.Command("git", "clone", "--depth", "1", repoURL, tmpDir)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("cloning repo: %s: %w", string(output), err)
+		return nil, err
 	}
-
-	skillsDir := tmpDir
-	if info, err := os.Stat(filepath.Join(tmpDir, "skills")); err == nil && info.IsDir() {
-		skillsDir = filepath.Join(tmpDir, "skills")
+	if len(skills) == 0 {
+		return nil, fmt.Errorf("no skills found in %s: expected subdirectories with a SKILL.md", srcDir)
 	}
-
-	return i.InstallFromLocal(skillsDir, destDir)
+	results, err := local.installSkills(skills, destDir, tags, languages)
+	i.deps = local.deps
+	return results, err
 }
 
-// InstallFromURL downloads and extracts a tarball of skills.
-func (i *Installer) InstallFromURL(url, destDir string) ([]string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("downloading %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("downloading %s: status %d", url, resp.StatusCode)
-	}
-
-	tmpDir, err := os.MkdirTemp("", "skill-installer-*")
-	if err != nil {
-		return nil, fmt.Errorf("creating temp dir: %w", err)
+// installFile writes a file and, when a manifest is attached, records it as
+// installer-owned. Files that were kept, or kept alongside a .new copy, are
+// not recorded.
+func (i *Installer) installFile(kind, name, filePath string, content []byte) (string, error) {
+	result, err := i.writeFile(filePath, content)
+	if err != nil || i.manifest == nil || i.options.DryRun {
+		return result, err
 	}
-	defer os.RemoveAll(tmpDir)
-
-	if err := extractTarGz(resp.Body, tmpDir); err != nil {
-		return nil, fmt.Errorf("extracting archive: %w", err)
+	if strings.HasPrefix(result, "SKIP:") || strings.HasPrefix(result, "KEPT BOTH:") {
+		return result, nil
 	}
-
-	return i.InstallFromLocal(tmpDir, destDir)
+	i.manifest.Record(kind, name, filePath, content)
+	return result, nil
 }
 
-func extractTarGz(r io.Reader, destDir string) error {
-	gzr, err := gzip.NewReader(r)
-	if err != nil {
-		return err
+// writeFile writes content to filePath. Existing files with identical
+// content are left alone and produce an empty result; ones that differ are
+// overwritten with Force, otherwise the OverwriteFunc (if any) decides and
+// they are skipped by default.
+func (i *Installer) writeFile(filePath string, content []byte) (string, error) {
+	exists := fileExists(filePath) && (i.txn == nil || !i.txn.removes(filePath))
+	if exists {
+		if current, err := HashFile(filePath); err == nil && current == HashBytes(content) {
+			return "", nil
+		}
 	}
-	defer gzr.Close()
 
-	tr := tar.NewReader(gzr)
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
+	target := filePath
+	if exists && !i.options.Force {
+		if i.overwrite == nil || i.options.DryRun {
+			return fmt.Sprintf("SKIP: %s (already exists, use --force to overwrite)", filePath), nil
 		}
+		existing, err := os.ReadFile(filePath)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("reading %s: %w", filePath, err)
 		}
-
-		target := filepath.Join(destDir, header.Name)
-
-		// Prevent zip-slip: ensure target stays within destDir
-		if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(destDir)+string(os.PathSeparator)) {
-			return fmt.Errorf("illegal path in archive: %s", header.Name)
+		choice, err := i.overwrite(filePath, existing, content)
+		if err != nil {
+			return "", err
 		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, 0755); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(f, tr); err != nil {
-				f.Close()
-				return err
-			}
-			f.Close()
+		switch choice {
+		case OverwriteKeep:
+			return fmt.Sprintf("SKIP: %s (kept existing)", filePath), nil
+		case OverwriteKeepBoth:
+			target = filePath + ".new"
 		}
 	}
-	return nil
-}
-
-func (i *Installer) writeFile(filePath string, content []byte) (string, error) {
-	exists := fileExists(filePath)
-
-	if exists && !i.options.Force {
-		return fmt.Sprintf("SKIP: %s (already exists, use --force to overwrite)", filePath), nil
-	}
 
 	if i.options.DryRun {
 		if exists {
@@ -381,92 +472,55 @@ func (i *Installer) writeFile(filePath string, content []byte) (string, error) {
 		return fmt.Sprintf("WOULD CREATE: %s", filePath), nil
 	}
 
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("creating directory %s: %w", dir, err)
-	}
-
-	if err := os.WriteFile(filePath, content, 0644); err != nil {
-		return "", fmt.Errorf("writing %s: %w", filePath, err)
+	if err := i.saveBackup(target, content); err != nil {
+		return "", err
 	}
 
-	if exists {
-		return fmt.Sprintf("UPDATED: %s", filePath), nil
-	}
-	return fmt.Sprintf("CREATED: %s", filePath), nil
-}
-
-func fileExists(filePath string) bool {
-	info, err := os.Stat(filePath)
-	return err == nil && !info.IsDir()
-}
-
-// parseSkill extracts skill metadata from frontmatter.
-func parseSkill(content []byte) (Skill, error) {
-	lines := strings.Split(string(content), "\n")
-
-	var skill Skill
-	skill.Content = content
-
-	inFrontmatter := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if trimmed == "---" {
-			if !inFrontmatter {
-				inFrontmatter = true
-				continue
-			}
-			break
+	if i.txn != nil {
+		if err := i.txn.Stage(target, content); err != nil {
+			return "", err
 		}
-
-		if !inFrontmatter {
-			continue
+	} else {
+		dir := filepath.Dir(target)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("creating directory %s: %w", dir, err)
 		}
 
-		if strings.HasPrefix(trimmed, "name:") {
-			skill.Name = strings.TrimSpace(strings.TrimPrefix(trimmed, "name:"))
-		} else if strings.HasPrefix(trimmed, "description:") {
-			val := strings.TrimSpace(strings.TrimPrefix(trimmed, "description:"))
-			skill.Description = val
-		} else if strings.HasPrefix(trimmed, "model:") {
-			skill.Model = strings.TrimSpace(strings.TrimPrefix(trimmed, "model:"))
-		} else if strings.HasPrefix(trimmed, "tags:") {
-			skill.Tags = parseYAMLList(strings.TrimPrefix(trimmed, "tags:"))
-		} else if strings.HasPrefix(trimmed, "languages:") {
-			skill.Languages = parseYAMLList(strings.TrimPrefix(trimmed, "languages:"))
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", target, err)
 		}
-		// Ignore unknown fields (allowed-tools, argument-hint, etc.)
 	}
 
-	if skill.Name == "" {
-		return skill, fmt.Errorf("skill missing name in frontmatter")
+	switch {
+	case target != filePath:
+		return fmt.Sprintf("KEPT BOTH: %s (new version written to %s)", filePath, target), nil
+	case exists:
+		return fmt.Sprintf("UPDATED: %s", filePath), nil
 	}
-	return skill, nil
+	return fmt.Sprintf("CREATED: %s", filePath), nil
 }
 
-// parseYAMLList parses a simple YAML list like [a, b, c].
-func parseYAMLList(s string) []string {
-	s = strings.TrimSpace(s)
-	if s == "" {
+// saveBackup snapshots filePath before it is overwritten with content, if
+// backups are on. Files that already hold content lose nothing and are skipped.
+func (i *Installer) saveBackup(filePath string, content []byte) error {
+	if i.backup == nil {
 		return nil
 	}
-
-	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
-		s = strings.TrimPrefix(s, "[")
-		s = strings.TrimSuffix(s, "]")
-		parts := strings.Split(s, ",")
-		var result []string
-		for _, p := range parts {
-			p = strings.TrimSpace(p)
-			if p != "" {
-				result = append(result, p)
-			}
-		}
-		return result
+	if current, err := HashFile(filePath); err == nil && current == HashBytes(content) {
+		return nil
 	}
+	return i.backup.Save(filePath)
+}
+
+func fileExists(filePath string) bool {
+	info, err := os.Stat(filePath)
+	return err == nil && !info.IsDir()
+}
 
-	return []string{s}
+// ParseSkill parses a SKILL.md file's frontmatter, reporting an error when
+// the frontmatter is missing or incomplete.
+func ParseSkill(content []byte) (Skill, error) {
+	return parseSkill(content)
 }
 
 func matchesFilter(skill Skill, tags, languages []string) bool {
@@ -568,7 +622,7 @@ You are a specialized agent for %s.
 ## Do NOT
 
 - [Things to avoid]
-`, name, description, model, tagsStr, langsStr,
+`, name, yamlString(description), model, tagsStr, langsStr,
 		titleCase(strings.ReplaceAll(name, "-", " ")),
 		description)
 }
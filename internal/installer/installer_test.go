@@ -51,6 +51,12 @@ func TestParseSkill_ExtraFields(t *testing.T) {
 	if skill.Name != "browser" {
 		t.Errorf("got name %q, want %q", skill.Name, "browser")
 	}
+	if len(skill.AllowedTools) != 1 || skill.AllowedTools[0] != "Bash" {
+		t.Errorf("got allowed-tools %q, want [Bash]", skill.AllowedTools)
+	}
+	if skill.ArgumentHint != "URL" {
+		t.Errorf("got argument-hint %q, want %q", skill.ArgumentHint, "URL")
+	}
 }
 
 func TestParseSkill_MissingName(t *testing.T) {
@@ -229,6 +235,48 @@ func TestInstallSkills_NoFilter(t *testing.T) {
 	}
 }
 
+func TestInstallFromLocal_DiscoversAndFilters(t *testing.T) {
+	src := t.TempDir()
+	writeSkill(t, src, "go-testing", "tags: [testing]\nlanguages: [go]\n")
+	writeSkill(t, src, "py-testing", "tags: [testing]\nlanguages: [python]\n")
+	writeSkill(t, src, "planning", "tags: [workflow]\n")
+	os.WriteFile(filepath.Join(src, "README.md"), []byte("# Skills"), 0644)
+	os.MkdirAll(filepath.Join(src, "tests"), 0755)
+	os.WriteFile(filepath.Join(src, "tests", "skills_test.sh"), []byte("#!/bin/sh\n"), 0644)
+	os.MkdirAll(filepath.Join(src, ".git"), 0755)
+	os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	os.MkdirAll(filepath.Join(src, "planning", ".git"), 0755)
+	os.WriteFile(filepath.Join(src, "planning", ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	os.WriteFile(filepath.Join(src, "planning", "notes.md"), []byte("notes"), 0644)
+
+	tests := []struct {
+		name            string
+		tags, languages []string
+		want            []string
+	}{
+		{"no filter", nil, nil, []string{
+			"go-testing", "go-testing/SKILL.md", "planning", "planning/SKILL.md", "planning/notes.md", "py-testing", "py-testing/SKILL.md",
+		}},
+		{"tag", []string{"testing"}, nil, []string{"go-testing", "go-testing/SKILL.md", "py-testing", "py-testing/SKILL.md"}},
+		{"tag and language", []string{"testing"}, []string{"go"}, []string{"go-testing", "go-testing/SKILL.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "skills")
+			if _, err := New(fstest.MapFS{}, Options{}).InstallFromLocal(src, dest, tt.tags, tt.languages); err != nil {
+				t.Fatal(err)
+			}
+			if got := listTree(t, dest); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("installed %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(fstest.MapFS{}, Options{}).InstallFromLocal(filepath.Join(src, "tests"), t.TempDir(), nil, nil); err == nil || !strings.Contains(err.Error(), "no skills found") {
+		t.Errorf("source without skills: %v", err)
+	}
+}
+
 func TestInstallAgents_DefaultNaming(t *testing.T) {
 	testFS := fstest.MapFS{
 		"agents/debugger.md": &fstest.MapFile{
@@ -507,26 +555,6 @@ func TestMatchesFilter(t *testing.T) {
 	}
 }
 
-func TestParseYAMLList(t *testing.T) {
-	tests := []struct {
-		input string
-		want  int
-	}{
-		{"[a, b, c]", 3},
-		{"[single]", 1},
-		{"value", 1},
-		{"", 0},
-		{"[  spaced , items  ]", 2},
-	}
-
-	for _, tt := range tests {
-		got := parseYAMLList(tt.input)
-		if len(got) != tt.want {
-			t.Errorf("parseYAMLList(%q) returned %d items, want %d", tt.input, len(got), tt.want)
-		}
-	}
-}
-
 func TestGenerateSkillTemplate(t *testing.T) {
 	result := GenerateSkillTemplate("my-skill", "A description", "opus", []string{"custom"}, []string{"go"})
 
@@ -592,3 +620,61 @@ func TestListSkills(t *testing.T) {
 		t.Errorf("ListAllSkills returned %d, ListSkills returned %d", len(allSkills), len(skills))
 	}
 }
+
+func TestWriteFile_IdenticalIsSilent(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "same.md")
+	os.WriteFile(testFile, []byte("same"), 0644)
+
+	for _, opts := range []Options{{}, {Force: true}, {DryRun: true}} {
+		inst := &Installer{options: opts}
+		result, err := inst.writeFile(testFile, []byte("same"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != "" {
+			t.Errorf("options %+v: result = %q, want empty", opts, result)
+		}
+	}
+}
+
+func TestWriteFile_OverwriteFunc(t *testing.T) {
+	tests := []struct {
+		choice     OverwriteChoice
+		wantPrefix string
+		wantFile   string
+		wantNew    bool
+	}{
+		{OverwriteKeep, "SKIP:", "original", false},
+		{OverwriteReplace, "UPDATED:", "new content", false},
+		{OverwriteKeepBoth, "KEPT BOTH:", "original", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantPrefix, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "agent.md")
+			os.WriteFile(testFile, []byte("original"), 0644)
+
+			var asked []string
+			inst := New(nil, Options{})
+			inst.SetOverwriteFunc(func(filePath string, existing, incoming []byte) (OverwriteChoice, error) {
+				asked = append(asked, string(existing)+"->"+string(incoming))
+				return tt.choice, nil
+			})
+			result, err := inst.writeFile(testFile, []byte("new content"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(result, tt.wantPrefix) {
+				t.Errorf("result = %q, want prefix %q", result, tt.wantPrefix)
+			}
+			if len(asked) != 1 || asked[0] != "original->new content" {
+				t.Errorf("OverwriteFunc calls = %v", asked)
+			}
+			if data, _ := os.ReadFile(testFile); string(data) != tt.wantFile {
+				t.Errorf("file = %q, want %q", data, tt.wantFile)
+			}
+			if data, err := os.ReadFile(testFile + ".new"); tt.wantNew != (err == nil) || (tt.wantNew && string(data) != "new content") {
+				t.Errorf(".new file = %q (err %v), want present=%v", data, err, tt.wantNew)
+			}
+		})
+	}
+}
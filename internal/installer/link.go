@@ -0,0 +1,353 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// storeDigestLen is how many hex digits of the content digest name a store
+// version.
+const storeDigestLen = 12
+
+// Store is a shared copy of skill content that linked installs point into,
+// kept under the user cache directory. Each version lives in a directory
+// named after a digest of its content, so projects linked to different
+// versions never disturb each other and identical content is stored once.
+type Store struct {
+	root string
+}
+
+// NewStore returns the store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{root: dir}
+}
+
+// DefaultStoreDir returns where the store lives: the skill-installer
+// directory in the user cache dir, or $SKILL_INSTALLER_CACHE_DIR if set.
+func DefaultStoreDir() (string, error) {
+	return cacheSubdir("store")
+}
+
+// cacheSubdir returns a directory inside the installer's cache.
+func cacheSubdir(name string) (string, error) {
+	if dir := os.Getenv("SKILL_INSTALLER_CACHE_DIR"); dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "skill-installer", name), nil
+}
+
+// Root returns the store's directory.
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Key returns the version key for the content under dir in fsys: label
+// (if any) followed by a digest of every file's path and content.
+func (s *Store) Key(label string, fsys fs.FS, dir string) (string, error) {
+	digest, err := contentDigest(fsys, dir)
+	if err != nil {
+		return "", err
+	}
+	if label == "" {
+		return digest, nil
+	}
+	return label + "-" + digest, nil
+}
+
+// contentDigest returns a short digest of every file's path and content
+// under dir in fsys.
+func contentDigest(fsys fs.FS, dir string) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dir), "/")
+		fmt.Fprintf(h, "%s\x00%s\n", rel, HashBytes(data))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", dir, err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:storeDigestLen], nil
+}
+
+// VersionDir returns the directory holding a store version.
+func (s *Store) VersionDir(key string) string {
+	return filepath.Join(s.root, key)
+}
+
+// Put copies the content under dir in fsys into the store, unless that
+// version is already there, and returns its key. Versions are written to a
+// temporary directory and renamed into place, so a version directory is
+// always complete. Stored files are read-only to keep linked copies from
+// drifting.
+func (s *Store) Put(label string, fsys fs.FS, dir string) (string, error) {
+	key, err := s.Key(label, fsys, dir)
+	if err != nil {
+		return "", err
+	}
+	versionDir := s.VersionDir(key)
+	if _, err := os.Stat(versionDir); err == nil {
+		return key, nil
+	}
+
+	if err := os.MkdirAll(s.root, 0755); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", s.root, err)
+	}
+	tmp, err := os.MkdirTemp(s.root, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("creating store directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	err = fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dir), "/")
+		target := filepath.Join(tmp, filepath.FromSlash(rel))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0444)
+	})
+	if err != nil {
+		return "", fmt.Errorf("populating store: %w", err)
+	}
+
+	if err := os.Rename(tmp, versionDir); err != nil {
+		// Another install may have stored the same version meanwhile.
+		if _, statErr := os.Stat(versionDir); statErr == nil {
+			return key, nil
+		}
+		return "", fmt.Errorf("populating store: %w", err)
+	}
+	return key, nil
+}
+
+// LinkSkills stores the installer's skills and symlinks the skill
+// directories matching the tag/language filter, and the skills they
+// require, into destDir.
+func (i *Installer) LinkSkills(store *Store, label, destDir string, tags, languages []string) ([]string, error) {
+	skills, err := i.discoverSkills()
+	if err != nil {
+		return nil, err
+	}
+	selected, err := i.selectSkills(skills, tags, languages)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, skill := range selected {
+		names = append(names, path.Base(skill.DirPath))
+	}
+	return i.linkFrom(store, label, i.fsys, "skills", destDir, names)
+}
+
+// RelinkSkills re-points the skills linked in m at the installer's current
+// content, e.g. after an upgrade.
+func (i *Installer) RelinkSkills(store *Store, label, destDir string, m *Manifest) ([]string, error) {
+	var names []string
+	for _, l := range m.Links {
+		names = append(names, l.Name)
+	}
+	return i.linkFrom(store, label, i.fsys, "skills", destDir, names)
+}
+
+// LinkFromLocal stores the skills in srcDir (see InstallFromLocal) and
+// symlinks those that match tags and languages, and the skills they
+// require, into destDir.
+func (i *Installer) LinkFromLocal(store *Store, srcDir, destDir string, tags, languages []string) ([]string, error) {
+	local := i.WithFS(os.DirFS(srcDir))
+	skills, err := local.discoverSkillsIn(".")
+	if err != nil {
+		return nil, err
+	}
+	if len(skills) == 0 {
+		return nil, fmt.Errorf("no skills found in %s: expected subdirectories with a SKILL.md", srcDir)
+	}
+	selected, err := local.selectSkills(skills, tags, languages)
+	i.deps = local.deps
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, skill := range selected {
+		names = append(names, path.Base(skill.DirPath))
+	}
+	return i.linkFrom(store, "", local.fsys, ".", destDir, names)
+}
+
+// linkFrom puts dir of fsys in the store and links the named skills.
+func (i *Installer) linkFrom(store *Store, label string, fsys fs.FS, dir, destDir string, names []string) ([]string, error) {
+	var key string
+	var err error
+	if i.options.DryRun {
+		key, err = store.Key(label, fsys, dir)
+	} else {
+		key, err = store.Put(label, fsys, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if i.manifest != nil && !i.options.DryRun {
+		i.manifest.Store = key
+	}
+
+	sort.Strings(names)
+	var results []string
+	for _, name := range names {
+		target, err := filepath.Abs(filepath.Join(store.VersionDir(key), name))
+		if err != nil {
+			return nil, err
+		}
+		result, err := i.linkSkill(name, filepath.Join(destDir, name), target)
+		if err != nil {
+			return nil, err
+		}
+		if result != "" {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// linkSkill points linkPath at target. An existing link is re-pointed
+// atomically by renaming a new link over it; a copied skill directory is
+// only replaced with Force (after backing up its files). In a transaction
+// both are staged, so a rollback restores what was there.
+func (i *Installer) linkSkill(name, linkPath, target string) (string, error) {
+	verb := "LINKED"
+	info, err := os.Lstat(linkPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return "", err
+	case info.Mode()&fs.ModeSymlink != 0:
+		current, _ := os.Readlink(linkPath)
+		if current == target {
+			if i.manifest != nil && !i.options.DryRun {
+				i.manifest.RecordLink(name, linkPath, target)
+			}
+			return "", nil
+		}
+		verb = "RELINKED"
+	case !i.options.Force:
+		return fmt.Sprintf("SKIP: %s (a copied install exists, use --force to replace it with a link)", linkPath), nil
+	default:
+		verb = "REPLACED"
+	}
+
+	if i.options.DryRun {
+		return fmt.Sprintf("WOULD LINK: %s -> %s", linkPath, target), nil
+	}
+
+	if verb == "REPLACED" {
+		if err := i.backupDir(linkPath); err != nil {
+			return "", err
+		}
+	}
+
+	if i.txn != nil {
+		if verb == "REPLACED" {
+			if err := i.txn.Remove(linkPath); err != nil {
+				return "", err
+			}
+		}
+		if err := i.txn.Link(linkPath, target); err != nil {
+			return "", err
+		}
+	} else if err := replaceWithLink(name, linkPath, target, verb == "REPLACED"); err != nil {
+		return "", err
+	}
+
+	if i.manifest != nil {
+		i.manifest.RecordLink(name, linkPath, target)
+	}
+	return fmt.Sprintf("%s: %s -> %s", verb, linkPath, target), nil
+}
+
+// replaceWithLink points linkPath at target right away, first removing the
+// directory there if removeDir is set.
+func replaceWithLink(name, linkPath, target string, removeDir bool) error {
+	if removeDir {
+		if err := os.RemoveAll(linkPath); err != nil {
+			return fmt.Errorf("removing %s: %w", linkPath, err)
+		}
+	}
+
+	dir := filepath.Dir(linkPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	tmp := filepath.Join(dir, "."+name+".link-tmp")
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("linking %s: %w", linkPath, err)
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("linking %s: %w", linkPath, err)
+	}
+	return nil
+}
+
+// backupDir snapshots every file under dir, if backups are on.
+func (i *Installer) backupDir(dir string) error {
+	if i.backup == nil {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return i.backup.Save(p)
+	})
+}
+
+// unlinkSkillDir prepares a linked skill directory to receive a copied
+// install. Without Force the skill is skipped, and a non-empty result says
+// so; with Force the link is removed (staged, if in a transaction).
+func (i *Installer) unlinkSkillDir(dir string) (string, bool, error) {
+	info, err := os.Lstat(dir)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return "", false, nil
+	}
+	if !i.options.Force {
+		return fmt.Sprintf("SKIP: %s (linked install, use --force to replace it with a copy)", dir), true, nil
+	}
+	if i.options.DryRun {
+		return fmt.Sprintf("WOULD UNLINK: %s", dir), false, nil
+	}
+
+	if i.txn != nil {
+		err = i.txn.Remove(dir)
+	} else {
+		err = os.Remove(dir)
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("removing link %s: %w", dir, err)
+	}
+	return fmt.Sprintf("UNLINKED: %s", dir), false, nil
+}
@@ -0,0 +1,304 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func linkTestFS(version string) fstest.MapFS {
+	return fstest.MapFS{
+		"skills/one/SKILL.md":            &fstest.MapFile{Data: []byte("---\nname: one\ntags: [go]\n---\n" + version)},
+		"skills/one/references/guide.md": &fstest.MapFile{Data: []byte("# Guide")},
+		"skills/two/SKILL.md":            &fstest.MapFile{Data: []byte("---\nname: two\ntags: [web]\n---\n")},
+	}
+}
+
+func TestLinkSkills(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+	skillsDir := filepath.Join(root, "skills")
+
+	m := NewManifest(root)
+	inst := New(linkTestFS("v1"), Options{})
+	inst.SetManifest(m)
+	results, err := inst.LinkSkills(store, "v1", skillsDir, []string{"go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "LINKED:") {
+		t.Fatalf("results = %v", results)
+	}
+
+	link := filepath.Join(skillsDir, "one")
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatalf("expected a symlink: %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(filepath.Dir(target)), "v1-") {
+		t.Errorf("link target %s not in a v1 store version", target)
+	}
+	if got := readString(t, filepath.Join(link, "references", "guide.md")); got != "# Guide" {
+		t.Errorf("guide.md through link = %q", got)
+	}
+	if len(m.Links) != 1 || m.Links[0].Path != "skills/one" || m.Store == "" {
+		t.Errorf("manifest links = %+v, store %q", m.Links, m.Store)
+	}
+
+	// Linking the same content again is a no-op
+	again, err := inst.LinkSkills(store, "v1", skillsDir, []string{"go"}, nil)
+	if err != nil || len(again) != 0 {
+		t.Errorf("relinking unchanged content = %v, %v", again, err)
+	}
+
+	// An upgrade re-points the link at a new store version
+	upgraded := New(linkTestFS("v2"), Options{})
+	upgraded.SetManifest(m)
+	results, err = upgraded.RelinkSkills(store, "v2", skillsDir, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "RELINKED:") {
+		t.Fatalf("upgrade results = %v", results)
+	}
+	if got := readString(t, filepath.Join(link, "SKILL.md")); !strings.HasSuffix(got, "v2") {
+		t.Errorf("SKILL.md after upgrade = %q", got)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(skillsDir, ".*")); len(leftovers) != 0 {
+		t.Errorf("temporary links left behind: %v", leftovers)
+	}
+}
+
+func TestLinkSkills_CopiedInstallNeedsForce(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	skillsDir := filepath.Join(dir, ".claude", "skills")
+	copied := filepath.Join(skillsDir, "one", "SKILL.md")
+	os.MkdirAll(filepath.Dir(copied), 0755)
+	os.WriteFile(copied, []byte("copied"), 0644)
+
+	results, err := New(linkTestFS("v1"), Options{}).LinkSkills(store, "v1", skillsDir, []string{"go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "SKIP:") {
+		t.Errorf("results = %v", results)
+	}
+
+	inst := New(linkTestFS("v1"), Options{Force: true})
+	b := NewBackup(filepath.Join(dir, ".claude"))
+	inst.SetBackup(b)
+	results, err = inst.LinkSkills(store, "v1", skillsDir, []string{"go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "REPLACED:") {
+		t.Errorf("forced results = %v", results)
+	}
+	if len(b.Files) != 1 {
+		t.Errorf("copied files backed up = %+v", b.Files)
+	}
+}
+
+func TestLinkSkills_Transaction(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+	skillsDir := filepath.Join(root, "skills")
+	copied := filepath.Join(skillsDir, "one", "SKILL.md")
+	os.MkdirAll(filepath.Dir(copied), 0755)
+	os.WriteFile(copied, []byte("copied"), 0644)
+
+	link := func() *Transaction {
+		tx := NewTransaction(root)
+		inst := New(linkTestFS("v1"), Options{Force: true})
+		inst.SetTransaction(tx)
+		results, err := inst.LinkSkills(store, "v1", skillsDir, []string{"go", "web"}, nil)
+		if err != nil || len(results) != 2 {
+			t.Fatalf("results = %v, %v", results, err)
+		}
+		if got := readString(t, copied); got != "copied" {
+			t.Errorf("copied skill changed before commit: %q", got)
+		}
+		if _, err := os.Lstat(filepath.Join(skillsDir, "two")); err == nil {
+			t.Error("link created before commit")
+		}
+		return tx
+	}
+
+	// A later failure puts the copied skill back and drops the new link
+	if err := link().Commit(func() error { return os.ErrPermission }); err == nil {
+		t.Fatal("expected commit to fail")
+	}
+	if got := readString(t, copied); got != "copied" {
+		t.Errorf("copied skill after rollback = %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(skillsDir, "two")); err == nil {
+		t.Error("link left behind by rollback")
+	}
+
+	if err := link().Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"one", "two"} {
+		if info, err := os.Lstat(filepath.Join(skillsDir, name)); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("%s is not a link after commit", name)
+		}
+	}
+}
+
+func TestStatus_LinkedSkills(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+	dests := UpdateTargets{SkillsDir: filepath.Join(root, "skills")}
+
+	m := NewManifest(root)
+	inst := New(linkTestFS("v1"), Options{})
+	inst.SetManifest(m)
+	if _, err := inst.LinkSkills(store, "v1", dests.SkillsDir, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := func(inst *Installer) map[string]string {
+		items, err := inst.Status(m, dests)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]string)
+		for _, item := range items {
+			if !item.Linked {
+				t.Errorf("%s not reported as linked", item.Name)
+			}
+			got[item.Name] = item.Status
+		}
+		return got
+	}
+
+	if got := statuses(inst); got["one"] != StatusUpToDate || got["two"] != StatusUpToDate {
+		t.Errorf("fresh link status = %v", got)
+	}
+	if got := statuses(New(linkTestFS("v2"), Options{})); got["one"] != StatusOutdated {
+		t.Errorf("status against newer content = %v", got)
+	}
+
+	os.Remove(filepath.Join(dests.SkillsDir, "two"))
+	os.MkdirAll(filepath.Join(dests.SkillsDir, "two"), 0755)
+	os.Remove(filepath.Join(dests.SkillsDir, "one"))
+	if got := statuses(inst); got["one"] != StatusMissing || got["two"] != StatusModified {
+		t.Errorf("status after local changes = %v", got)
+	}
+}
+
+func TestInstallSkills_ReplacesLinkWithoutTouchingStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+	skillsDir := filepath.Join(root, "skills")
+
+	m := NewManifest(root)
+	linker := New(linkTestFS("v1"), Options{})
+	linker.SetManifest(m)
+	if _, err := linker.LinkSkills(store, "v1", skillsDir, []string{"go"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	storeFile := filepath.Join(m.Links[0].Target, "SKILL.md")
+
+	results, err := New(linkTestFS("v2"), Options{}).InstallSkills(skillsDir, []string{"go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "SKIP:") {
+		t.Errorf("copy over link without force = %v", results)
+	}
+
+	tx := NewTransaction(root)
+	inst := New(linkTestFS("v2"), Options{Force: true})
+	inst.SetManifest(m)
+	inst.SetTransaction(tx)
+	if _, err := inst.InstallSkills(skillsDir, []string{"go"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(filepath.Join(skillsDir, "one"))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("expected a copied directory, got %v, %v", info, err)
+	}
+	if got := readString(t, filepath.Join(skillsDir, "one", "SKILL.md")); !strings.HasSuffix(got, "v2") {
+		t.Errorf("copied SKILL.md = %q", got)
+	}
+	if got := readString(t, storeFile); !strings.HasSuffix(got, "v1") {
+		t.Errorf("store content changed: %q", got)
+	}
+	if len(m.Links) != 0 {
+		t.Errorf("manifest still lists links: %+v", m.Links)
+	}
+}
+
+func TestUninstall_RemovesLinks(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+
+	m := NewManifest(root)
+	inst := New(linkTestFS("v1"), Options{})
+	inst.SetManifest(m)
+	if _, err := inst.LinkSkills(store, "v1", filepath.Join(root, "skills"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	target := m.Links[0].Target
+
+	results, err := New(nil, Options{}).Uninstall(m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !strings.HasPrefix(results[0], "REMOVED:") {
+		t.Errorf("results = %v", results)
+	}
+	if paths := listTree(t, root); len(paths) != 0 {
+		t.Errorf("uninstall left %v", paths)
+	}
+	if !isSkillDir(target) {
+		t.Error("uninstall removed content from the store")
+	}
+}
+
+func TestUninstall_KeepsLinkReplacedByDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "store"))
+	root := filepath.Join(dir, ".claude")
+
+	m := NewManifest(root)
+	inst := New(linkTestFS("v1"), Options{})
+	inst.SetManifest(m)
+	if _, err := inst.LinkSkills(store, "v1", filepath.Join(root, "skills"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	own := filepath.Join(root, "skills", "one", "SKILL.md")
+	os.Remove(filepath.Dir(own))
+	os.MkdirAll(filepath.Dir(own), 0755)
+	os.WriteFile(own, []byte("my own"), 0644)
+
+	results, err := New(nil, Options{Force: true}).Uninstall(m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !strings.HasPrefix(results[0], "KEPT:") || !strings.HasPrefix(results[1], "REMOVED:") {
+		t.Errorf("results = %v", results)
+	}
+	if got := readString(t, own); got != "my own" {
+		t.Errorf("replaced skill = %q", got)
+	}
+}
@@ -0,0 +1,407 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Bounds on skill frontmatter, as Claude Code enforces them.
+const (
+	MaxNameLength        = 64
+	MinDescriptionLength = 20
+	MaxDescriptionLength = 1024
+)
+
+// DefaultTags is the tag vocabulary lint accepts without configuration.
+var DefaultTags = []string{
+	"adonisjs", "api", "architecture", "auth", "authoring", "browser",
+	"database", "debugging", "design", "development", "framework", "frontend",
+	"illustration", "javascript", "marketing", "quality", "search", "seo",
+	"testing", "tools", "workflow",
+}
+
+// Lint severities.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a problem Lint found in a file.
+type LintIssue struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+// LintReport lists what Lint checked and the issues it found, ordered by
+// path and line.
+type LintReport struct {
+	Skills   int         `json:"skills"`
+	Agents   int         `json:"agents"`
+	Commands int         `json:"commands"`
+	Issues   []LintIssue `json:"issues"`
+}
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	Tags []string // Tags allowed besides DefaultTags
+}
+
+// skillName is the form Claude Code accepts for names: lowercase words
+// joined by single hyphens.
+var skillName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// markdownLink finds the targets of inline markdown links.
+var markdownLink = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+// resourceDirs are the skill subdirectories whose links lint resolves.
+var resourceDirs = []string{"references", "scripts", "assets", "templates"}
+
+// Lint checks the skills, agents, and commands in each path before they are
+// published. A path is either a single skill directory or a source laid out
+// as --from expects. Names are checked for duplicates across all paths.
+func Lint(paths []string, opts LintOptions) (*LintReport, error) {
+	l := &linter{
+		report: &LintReport{Issues: []LintIssue{}},
+		tags:   make(map[string]bool),
+		seen:   make(map[string]string),
+	}
+	for _, t := range append(append([]string{}, DefaultTags...), opts.Tags...) {
+		l.tags[t] = true
+	}
+
+	for _, p := range paths {
+		if !dirExists(p) {
+			return nil, fmt.Errorf("%s is not a directory", p)
+		}
+		if isSkillDir(p) {
+			l.skill(p)
+			continue
+		}
+		layout, err := ResolveLayout(p, "")
+		if err != nil {
+			return nil, err
+		}
+		l.sourceSkills, l.sourceAgents = nil, nil
+		if err := l.skills(layout.Skills); err != nil {
+			return nil, err
+		}
+		if dir := layout.Agents(); dir != "" {
+			if err := l.markdown(dir, "agent"); err != nil {
+				return nil, err
+			}
+		}
+		if dir := layout.Commands(); dir != "" {
+			if err := l.markdown(dir, "command"); err != nil {
+				return nil, err
+			}
+		}
+		if _, _, err := resolveRequires(l.sourceSkills, l.sourceSkills, l.sourceAgents, true); err != nil {
+			l.add(p, 0, LintError, "requires", "%v", err)
+		}
+	}
+
+	sort.SliceStable(l.report.Issues, func(a, b int) bool {
+		x, y := l.report.Issues[a], l.report.Issues[b]
+		if x.Path != y.Path {
+			return x.Path < y.Path
+		}
+		return x.Line < y.Line
+	})
+	return l.report, nil
+}
+
+type linter struct {
+	report       *LintReport
+	tags         map[string]bool
+	seen         map[string]string // kind and name to the first file seen with it
+	sourceSkills []Skill           // The current source's skills, for requires:
+	sourceAgents []Agent           // The current source's agents, for requires:
+}
+
+func (l *linter) add(file string, line int, severity, check, format string, args ...any) {
+	l.report.Issues = append(l.report.Issues, LintIssue{
+		Path:     file,
+		Line:     line,
+		Severity: severity,
+		Check:    check,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// invalid reports frontmatter that can't be parsed.
+func (l *linter) invalid(file string, err error) {
+	var lineErr *lineError
+	if errors.As(err, &lineErr) {
+		l.add(file, lineErr.line, LintError, "frontmatter", "%s", lineErr.msg)
+		return
+	}
+	l.add(file, 1, LintError, "frontmatter", "%v", err)
+}
+
+// skills lints every skill in dir. Subdirectories of a skills/ directory
+// without a SKILL.md are reported, as installs would skip them.
+func (l *linter) skills(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		if !isSkillDir(p) {
+			if filepath.Base(dir) != "skills" {
+				continue
+			}
+			l.add(p, 0, LintWarning, "layout", "%s has no SKILL.md, so it is not installed as a skill", e.Name())
+			continue
+		}
+		l.skill(p)
+	}
+	return nil
+}
+
+// skill lints one skill directory.
+func (l *linter) skill(dir string) {
+	l.report.Skills++
+	file := filepath.Join(dir, "SKILL.md")
+	if !fileExists(file) {
+		file = filepath.Join(dir, "skill.md")
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		l.add(file, 0, LintError, "frontmatter", "%v", err)
+		return
+	}
+	dirName := filepath.Base(dir)
+	l.duplicate("skill", dirName, file)
+	l.sourceSkills = append(l.sourceSkills, Skill{DirPath: dirName})
+	l.links(dir, file, content)
+	l.scripts(dir)
+
+	if !bytes.HasPrefix(content, []byte("---")) {
+		l.invalid(file, errNoFrontmatter)
+		return
+	}
+	root, first, err := parseFrontmatter(content)
+	if err != nil {
+		l.invalid(file, err)
+		return
+	}
+	var skill Skill
+	if root != nil {
+		if err := skill.setFields(root, first); err != nil {
+			l.invalid(file, err)
+			return
+		}
+	}
+	lines := fieldLines(root, first)
+	l.sourceSkills[len(l.sourceSkills)-1].Requires = skill.Requires
+
+	if skill.Name == "" {
+		l.add(file, first-1, LintError, "required", "name is missing")
+	} else {
+		l.name(file, lines["name"], skill.Name, dirName)
+	}
+	l.description(file, lines["description"], first-1, skill.Description, true)
+
+	if len(skill.Tags) == 0 {
+		l.add(file, first-1, LintWarning, "tags", "no tags, so --tag never selects this skill")
+	}
+	for _, t := range skill.Tags {
+		if !l.tags[t] {
+			l.add(file, lines["tags"], LintWarning, "tags", "tag %q is not in the vocabulary; use a known tag or add it to lint.tags in the config", t)
+		}
+	}
+}
+
+// name checks a name's form and that it matches the file or directory it
+// is installed as.
+func (l *linter) name(file string, line int, name, want string) {
+	switch {
+	case len(name) > MaxNameLength:
+		l.add(file, line, LintError, "name", "name is %d characters, over the limit of %d", len(name), MaxNameLength)
+	case !skillName.MatchString(name):
+		l.add(file, line, LintError, "name", "name %q must be lowercase letters and digits separated by single hyphens", name)
+	}
+	if name != want {
+		l.add(file, line, LintError, "name", "name %q does not match %q, the name it is installed under", name, want)
+	}
+}
+
+// description checks a description's length. Skills need one, as it is
+// how Claude decides when to use them.
+func (l *linter) description(file string, line, start int, desc string, required bool) {
+	n := len([]rune(desc))
+	switch {
+	case n == 0 && required:
+		l.add(file, start, LintError, "required", "description is missing")
+	case n == 0:
+	case n > MaxDescriptionLength:
+		l.add(file, line, LintError, "description", "description is %d characters, over the limit of %d", n, MaxDescriptionLength)
+	case n < MinDescriptionLength:
+		l.add(file, line, LintWarning, "description", "description is %d characters; say what the skill does and when to use it in at least %d", n, MinDescriptionLength)
+	}
+}
+
+// duplicate reports a name already seen for the same kind of content, in
+// this source or another.
+func (l *linter) duplicate(kind, name, file string) {
+	key := kind + "\x00" + name
+	if first, ok := l.seen[key]; ok {
+		l.add(file, 0, LintError, "duplicates", "%s %s is also defined in %s; only one would be installed", kind, name, first)
+		return
+	}
+	l.seen[key] = file
+}
+
+// links reports links into the skill's resource directories that do not
+// resolve. Links in code blocks are examples and are left alone.
+func (l *linter) links(dir, file string, content []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1<<20)
+	fence := ""
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		}
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		for _, m := range markdownLink.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if i := strings.IndexAny(target, "#?"); i >= 0 {
+				target = target[:i]
+			}
+			if unescaped, err := url.PathUnescape(target); err == nil {
+				target = unescaped
+			}
+			clean := path.Clean(target)
+			top, _, _ := strings.Cut(clean, "/")
+			if !contains(resourceDirs, top) {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(clean))); err != nil {
+				l.add(file, n, LintError, "links", "link to %s does not resolve to a file in the skill", m[1])
+			}
+		}
+	}
+}
+
+// scripts reports files whose exec intent is unclear: a #! line without
+// the executable bit, or the executable bit without a #! line.
+func (l *linter) scripts(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return nil
+		}
+		head := make([]byte, 2)
+		n, _ := f.Read(head)
+		f.Close()
+		shebang := n == 2 && string(head) == "#!"
+		executable := info.Mode().Perm()&0111 != 0
+		rel, _ := filepath.Rel(dir, p)
+		switch {
+		case shebang && !executable:
+			l.add(p, 1, LintError, "scripts", "%s starts with #! but is not executable; run chmod +x on it", rel)
+		case executable && !shebang && strings.HasPrefix(filepath.ToSlash(rel), "scripts/"):
+			l.add(p, 0, LintWarning, "scripts", "%s is executable but has no #! line to say how to run it", rel)
+		}
+		return nil
+	})
+}
+
+// markdown lints the agents or commands under dir. They need no
+// frontmatter, but what they have must parse and agree with the file name.
+func (l *linter) markdown(dir, kind string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && p != dir {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+		if kind == "agent" {
+			l.report.Agents++
+		} else {
+			l.report.Commands++
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+		if strings.HasSuffix(name, "/COMMAND") {
+			name = strings.TrimSuffix(name, "/COMMAND")
+		}
+		l.duplicate(kind, name, p)
+		var agent *Agent
+		if kind == "agent" && !strings.Contains(name, "/") {
+			l.sourceAgents = append(l.sourceAgents, Agent{Name: name})
+			agent = &l.sourceAgents[len(l.sourceAgents)-1]
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(content, []byte("---")) {
+			return nil
+		}
+		root, first, err := parseFrontmatter(content)
+		if err != nil {
+			l.invalid(p, err)
+			return nil
+		}
+
+		lines := fieldLines(root, first)
+		var meta Skill
+		if root != nil {
+			if err := meta.setFields(root, first); err != nil {
+				l.invalid(p, err)
+				return nil
+			}
+		}
+		if agent != nil {
+			agent.Requires = meta.Requires
+		}
+		if meta.Name != "" {
+			l.name(p, lines["name"], meta.Name, path.Base(name))
+		}
+		l.description(p, lines["description"], first-1, meta.Description, false)
+		return nil
+	})
+}
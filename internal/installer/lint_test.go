@@ -0,0 +1,99 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// lintSummary renders issues as "file:line check severity" with paths
+// relative to root, for comparison.
+func lintSummary(t *testing.T, root string, issues []LintIssue) []string {
+	t.Helper()
+	var out []string
+	for _, i := range issues {
+		rel, err := filepath.Rel(root, i.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, fmt.Sprintf("%s:%d %s %s", filepath.ToSlash(rel), i.Line, i.Check, i.Severity))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestLint(t *testing.T) {
+	root := t.TempDir()
+	skills := filepath.Join(root, "skills")
+	desc := "description: Reviews code for style and correctness\n"
+
+	writeSkill(t, skills, "good", desc+"tags: [quality]\n")
+	os.MkdirAll(filepath.Join(skills, "good", "references"), 0755)
+	os.WriteFile(filepath.Join(skills, "good", "references", "guide.md"), []byte("# Guide"), 0644)
+	os.MkdirAll(filepath.Join(skills, "good", "scripts"), 0755)
+	os.WriteFile(filepath.Join(skills, "good", "scripts", "run.sh"), []byte("#!/bin/sh\necho hi\n"), 0755)
+	os.WriteFile(filepath.Join(skills, "good", "SKILL.md"), []byte("---\nname: good\n"+desc+"tags: [quality]\n---\n"+
+		"See [the guide](references/guide.md#usage) and [run](./scripts/run.sh).\n"+
+		"```\n[example](references/not-real.md)\n```\n"), 0644)
+
+	writeSkill(t, skills, "broken", "description: uses: colons\n")
+	writeSkill(t, skills, "Misnamed", desc+"tags: [quality]\n")
+	writeSkill(t, skills, "renamed", desc+"tags: [quality]\n")
+	os.WriteFile(filepath.Join(skills, "renamed", "SKILL.md"), []byte("---\nname: other\n"+desc+"tags: [quality]\n---\n"), 0644)
+	writeSkill(t, skills, "terse", "description: Reviews\ntags: [quality, unheard-of]\n")
+	writeSkill(t, skills, "vague", "tags: [quality]\n")
+	writeSkill(t, skills, "long", "description: "+strings.Repeat("x", MaxDescriptionLength+1)+"\n")
+	writeSkill(t, skills, "linked", desc+"tags: [quality]\n")
+	os.WriteFile(filepath.Join(skills, "linked", "SKILL.md"), []byte("---\nname: linked\n"+desc+"tags: [quality]\n---\n\nRead [this](references/missing.md).\n"), 0644)
+	writeSkill(t, skills, "scripted", desc+"tags: [quality]\n")
+	os.MkdirAll(filepath.Join(skills, "scripted", "scripts"), 0755)
+	os.WriteFile(filepath.Join(skills, "scripted", "scripts", "lost.py"), []byte("#!/usr/bin/env python3\n"), 0644)
+	os.WriteFile(filepath.Join(skills, "scripted", "scripts", "bare"), []byte("echo hi\n"), 0755)
+	os.MkdirAll(filepath.Join(skills, "notes"), 0755)
+	writeSkill(t, skills, "needy", desc+"tags: [quality]\nrequires: [good, ghost]\n")
+
+	os.MkdirAll(filepath.Join(root, "agents"), 0755)
+	os.WriteFile(filepath.Join(root, "agents", "plain.md"), []byte("# Plain agent\n\n---\n"), 0644)
+	os.WriteFile(filepath.Join(root, "agents", "helper.md"), []byte("---\nname: assistant\n---\n"), 0644)
+
+	// A second source shipping a skill of the same name
+	other := t.TempDir()
+	writeSkill(t, other, "good", desc+"tags: [team]\n")
+
+	report, err := Lint([]string{root, filepath.Join(other, "good")}, LintOptions{Tags: []string{"team"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Skills != 11 || report.Agents != 2 || report.Commands != 0 {
+		t.Errorf("checked %d skills, %d agents, %d commands", report.Skills, report.Agents, report.Commands)
+	}
+
+	got := lintSummary(t, root, report.Issues)
+	want := []string{
+		"../" + filepath.Base(other) + "/good/SKILL.md:0 duplicates error",
+		".:0 requires error",
+		"agents/helper.md:2 name error",
+		"skills/Misnamed/SKILL.md:2 name error",
+		"skills/broken/SKILL.md:3 frontmatter error",
+		"skills/linked/SKILL.md:7 links error",
+		"skills/long/SKILL.md:1 tags warning",
+		"skills/long/SKILL.md:3 description error",
+		"skills/notes:0 layout warning",
+		"skills/renamed/SKILL.md:2 name error",
+		"skills/scripted/scripts/bare:0 scripts warning",
+		"skills/scripted/scripts/lost.py:1 scripts error",
+		"skills/terse/SKILL.md:3 description warning",
+		"skills/terse/SKILL.md:4 tags warning",
+		"skills/vague/SKILL.md:1 required error",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Lint([]string{filepath.Join(root, "missing")}, LintOptions{}); err == nil {
+		t.Error("expected error for a missing path")
+	}
+}
@@ -0,0 +1,413 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestName is the filename of the install manifest, written next to the
+// target's skills directory (e.g. .claude/.skill-installer.lock).
+const ManifestName = ".skill-installer.lock"
+
+// StateDirName is the directory next to the manifest holding installer state
+// that should not be committed, such as pristine copies of installed files.
+const StateDirName = ".skill-installer"
+
+// Kinds of installed files recorded in the manifest.
+const (
+	KindSkill   = "skill"
+	KindAgent   = "agent"
+	KindCommand = "command"
+)
+
+// SourceEmbedded is the manifest source for content shipped inside the binary.
+const SourceEmbedded = "embedded"
+
+// ManifestFile is a single file written by the installer.
+type ManifestFile struct {
+	Path   string `json:"path"` // Slash-separated, relative to the manifest root
+	Kind   string `json:"kind"` // skill, agent or command
+	Name   string `json:"name"` // Skill directory, agent filename or command path
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestLink is a skill directory installed as a symlink into the store.
+type ManifestLink struct {
+	Path   string `json:"path"`   // Slash-separated, relative to the manifest root
+	Name   string `json:"name"`   // Skill directory
+	Target string `json:"target"` // Directory in the store the link points to
+}
+
+// ManifestPackage is a skill added from a registry. Its files are recorded
+// like any other skill's, but Update leaves it to the registry.
+type ManifestPackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Registry string `json:"registry"`
+	SHA256   string `json:"sha256"` // Digest of the archive it came from
+}
+
+// Manifest records everything a previous install wrote, so later commands can
+// tell installer-owned files apart from user-authored ones.
+type Manifest struct {
+	InstallerVersion string            `json:"installer_version"`
+	Target           string            `json:"target"`
+	Scope            string            `json:"scope"`
+	Source           string            `json:"source"`
+	Commit           string            `json:"commit,omitempty"` // Commit a git source resolved to
+	InstalledAt      time.Time         `json:"installed_at"`
+	Skills           []string          `json:"skills"`
+	Agents           []string          `json:"agents"`
+	Commands         []string          `json:"commands"`
+	Files            []ManifestFile    `json:"files"`
+	Store            string            `json:"store,omitempty"` // Store version linked skills point into
+	Links            []ManifestLink    `json:"links,omitempty"`
+	Packages         []ManifestPackage `json:"packages,omitempty"`
+
+	root    string
+	objects map[string][]byte // content recorded since load, keyed by SHA-256
+}
+
+// NewManifest creates an empty manifest rooted at dir.
+func NewManifest(root string) *Manifest {
+	return &Manifest{root: root, objects: make(map[string][]byte)}
+}
+
+// LoadManifest reads the manifest in root. A missing manifest is not an error;
+// an empty one is returned instead.
+func LoadManifest(root string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(root, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewManifest(root), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Join(root, ManifestName), err)
+	}
+	m.root = root
+	m.objects = make(map[string][]byte)
+	return &m, nil
+}
+
+// Root returns the directory the manifest lives in.
+func (m *Manifest) Root() string {
+	return m.root
+}
+
+// Path returns the manifest's own file path.
+func (m *Manifest) Path() string {
+	return filepath.Join(m.root, ManifestName)
+}
+
+// Exists reports whether the manifest has been saved to disk before.
+func (m *Manifest) Exists() bool {
+	return fileExists(m.Path())
+}
+
+// AbsPath resolves a manifest entry to a filesystem path.
+func (m *Manifest) AbsPath(f ManifestFile) string {
+	return filepath.Join(m.root, filepath.FromSlash(f.Path))
+}
+
+// Record adds or replaces the entry for filePath. A copy of content is kept
+// so later updates can three-way merge against what was installed.
+func (m *Manifest) Record(kind, name, filePath string, content []byte) {
+	rel, err := filepath.Rel(m.root, filePath)
+	if err != nil {
+		rel = filePath
+	}
+	entry := ManifestFile{
+		Path:   filepath.ToSlash(rel),
+		Kind:   kind,
+		Name:   name,
+		SHA256: HashBytes(content),
+	}
+	m.objects[entry.SHA256] = content
+	if kind == KindSkill {
+		m.forgetLinks(name)
+	}
+
+	for idx, f := range m.Files {
+		if f.Path == entry.Path {
+			m.Files[idx] = entry
+			return
+		}
+	}
+	m.Files = append(m.Files, entry)
+}
+
+// Forget drops the entry for a manifest-relative path.
+func (m *Manifest) Forget(relPath string) {
+	for idx, f := range m.Files {
+		if f.Path == relPath {
+			m.Files = append(m.Files[:idx], m.Files[idx+1:]...)
+			return
+		}
+	}
+}
+
+// RecordLink adds or replaces the entry for a linked skill directory,
+// dropping any copied files previously recorded for that skill.
+func (m *Manifest) RecordLink(name, linkPath, target string) {
+	rel, err := filepath.Rel(m.root, linkPath)
+	if err != nil {
+		rel = linkPath
+	}
+	kept := m.Files[:0]
+	for _, f := range m.Files {
+		if f.Kind != KindSkill || f.Name != name {
+			kept = append(kept, f)
+		}
+	}
+	m.Files = kept
+
+	m.forgetLinks(name)
+	m.Links = append(m.Links, ManifestLink{Path: filepath.ToSlash(rel), Name: name, Target: target})
+}
+
+// forgetLinks drops the link entries for a skill.
+func (m *Manifest) forgetLinks(name string) {
+	kept := m.Links[:0]
+	for _, l := range m.Links {
+		if l.Name != name {
+			kept = append(kept, l)
+		}
+	}
+	m.Links = kept
+}
+
+// RecordPackage adds or replaces the entry for a skill added from a registry.
+func (m *Manifest) RecordPackage(p ManifestPackage) {
+	for idx, existing := range m.Packages {
+		if existing.Name == p.Name {
+			m.Packages[idx] = p
+			return
+		}
+	}
+	m.Packages = append(m.Packages, p)
+}
+
+// Package returns the registry entry for a skill, if it was added from one.
+func (m *Manifest) Package(name string) (ManifestPackage, bool) {
+	for _, p := range m.Packages {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ManifestPackage{}, false
+}
+
+// FromEmbedded reports whether the installed content came from the binary,
+// which can be compared against and updated to, rather than from --from or
+// the config's sources.
+func (m *Manifest) FromEmbedded() bool {
+	return m.Source == "" || m.Source == SourceEmbedded
+}
+
+// HasSkill reports whether a skill of that name is installed, ignoring case
+// the way Uninstall matches names.
+func (m *Manifest) HasSkill(name string) bool {
+	return contains(m.Skills, name)
+}
+
+// LinkPath resolves a link entry to a filesystem path.
+func (m *Manifest) LinkPath(l ManifestLink) string {
+	return filepath.Join(m.root, filepath.FromSlash(l.Path))
+}
+
+// Empty reports whether the manifest records nothing installed.
+func (m *Manifest) Empty() bool {
+	return len(m.Files) == 0 && len(m.Links) == 0
+}
+
+// Object returns the originally installed content for a file hash.
+func (m *Manifest) Object(sha string) ([]byte, error) {
+	if content, ok := m.objects[sha]; ok {
+		return content, nil
+	}
+	return os.ReadFile(m.objectPath(sha))
+}
+
+func (m *Manifest) objectsDir() string {
+	return filepath.Join(m.root, StateDirName, "objects")
+}
+
+func (m *Manifest) objectPath(sha string) string {
+	return filepath.Join(m.objectsDir(), sha[:2], sha)
+}
+
+// Lookup returns the entry for a filesystem path, if the installer owns it.
+func (m *Manifest) Lookup(filePath string) (ManifestFile, bool) {
+	rel, err := filepath.Rel(m.root, filePath)
+	if err != nil {
+		return ManifestFile{}, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, f := range m.Files {
+		if f.Path == rel {
+			return f, true
+		}
+	}
+	return ManifestFile{}, false
+}
+
+// Save writes the manifest to disk, refreshing the name summaries.
+func (m *Manifest) Save() error {
+	sort.Slice(m.Files, func(a, b int) bool { return m.Files[a].Path < m.Files[b].Path })
+	sort.Slice(m.Links, func(a, b int) bool { return m.Links[a].Path < m.Links[b].Path })
+	m.Skills = m.names(KindSkill)
+	kept := m.Packages[:0]
+	for _, p := range m.Packages {
+		if contains(m.Skills, p.Name) {
+			kept = append(kept, p)
+		}
+	}
+	m.Packages = kept
+	sort.Slice(m.Packages, func(a, b int) bool { return m.Packages[a].Name < m.Packages[b].Name })
+	for _, l := range m.Links {
+		m.Skills = append(m.Skills, l.Name)
+	}
+	sort.Strings(m.Skills)
+	if len(m.Links) == 0 {
+		m.Store = ""
+	}
+	m.Agents = m.names(KindAgent)
+	m.Commands = m.names(KindCommand)
+	if m.InstalledAt.IsZero() {
+		m.InstalledAt = time.Now().UTC()
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.root, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", m.root, err)
+	}
+	if err := os.WriteFile(m.Path(), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", m.Path(), err)
+	}
+	return m.saveObjects()
+}
+
+// saveObjects writes pending object content and removes objects no longer
+// referenced by any entry.
+func (m *Manifest) saveObjects() error {
+	referenced := make(map[string]bool)
+	for _, f := range m.Files {
+		referenced[f.SHA256] = true
+	}
+
+	for sha, content := range m.objects {
+		if !referenced[sha] {
+			continue
+		}
+		p := m.objectPath(sha)
+		if fileExists(p) {
+			continue
+		}
+		if err := ensureStateDir(m.root); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("creating directory %s: %w", filepath.Dir(p), err)
+		}
+		if err := os.WriteFile(p, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", p, err)
+		}
+	}
+	m.objects = make(map[string][]byte)
+
+	return filepath.WalkDir(m.objectsDir(), func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !referenced[d.Name()] {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			pruneEmptyDirs(filepath.Dir(p), m.objectsDir())
+		}
+		return nil
+	})
+}
+
+// removeState deletes the stored objects, used once the manifest is empty.
+func (m *Manifest) removeState() error {
+	if err := os.RemoveAll(m.objectsDir()); err != nil {
+		return err
+	}
+	stateDir := filepath.Join(m.root, StateDirName)
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		return nil
+	}
+	if len(entries) == 1 && entries[0].Name() == ".gitignore" {
+		return os.RemoveAll(stateDir)
+	}
+	return nil
+}
+
+// ensureStateDir creates the state directory with a .gitignore that keeps
+// its contents out of version control.
+func ensureStateDir(root string) error {
+	dir := filepath.Join(root, StateDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if fileExists(ignore) {
+		return nil
+	}
+	if err := os.WriteFile(ignore, []byte("*\n"), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", ignore, err)
+	}
+	return nil
+}
+
+// names returns the sorted, de-duplicated names of all copied entries of a
+// kind. Linked skills are not included.
+func (m *Manifest) names(kind string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, f := range m.Files {
+		if f.Kind != kind || seen[f.Name] {
+			continue
+		}
+		seen[f.Name] = true
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HashBytes returns the hex-encoded SHA-256 of b.
+func HashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFile returns the hex-encoded SHA-256 of the file at filePath.
+func HashFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return HashBytes(data), nil
+}
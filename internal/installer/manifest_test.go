@@ -0,0 +1,173 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadManifest_Missing(t *testing.T) {
+	root := t.TempDir()
+	m, err := LoadManifest(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Exists() {
+		t.Error("Exists() = true for a manifest that was never saved")
+	}
+	if len(m.Files) != 0 {
+		t.Errorf("expected empty manifest, got %d files", len(m.Files))
+	}
+}
+
+func TestManifest_SaveAndLoad(t *testing.T) {
+	root := t.TempDir()
+	m := NewManifest(root)
+	m.InstallerVersion = "1.2.3"
+	m.Target = "claude"
+	m.Scope = "project"
+	m.Source = SourceEmbedded
+	m.Record(KindSkill, "b-skill", filepath.Join(root, "skills", "b-skill", "SKILL.md"), []byte("b"))
+	m.Record(KindSkill, "a-skill", filepath.Join(root, "skills", "a-skill", "SKILL.md"), []byte("a"))
+	m.Record(KindAgent, "debugger.md", filepath.Join(root, "agents", "debugger.md"), []byte("d"))
+
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := LoadManifest(root)
+	if err != nil {
+		t.Fatalf("LoadManifest() error: %v", err)
+	}
+	if loaded.InstallerVersion != "1.2.3" || loaded.Target != "claude" || loaded.Scope != "project" {
+		t.Errorf("header not round-tripped: %+v", loaded)
+	}
+	if len(loaded.Files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(loaded.Files))
+	}
+	if loaded.Files[0].Path != "agents/debugger.md" {
+		t.Errorf("files not sorted by path: first = %q", loaded.Files[0].Path)
+	}
+	if len(loaded.Skills) != 2 || loaded.Skills[0] != "a-skill" {
+		t.Errorf("Skills = %v, want [a-skill b-skill]", loaded.Skills)
+	}
+	if len(loaded.Agents) != 1 || loaded.Agents[0] != "debugger.md" {
+		t.Errorf("Agents = %v, want [debugger.md]", loaded.Agents)
+	}
+	if loaded.InstalledAt.IsZero() {
+		t.Error("InstalledAt not set on save")
+	}
+}
+
+func TestManifest_RecordReplaces(t *testing.T) {
+	root := t.TempDir()
+	m := NewManifest(root)
+	p := filepath.Join(root, "skills", "x", "SKILL.md")
+	m.Record(KindSkill, "x", p, []byte("one"))
+	m.Record(KindSkill, "x", p, []byte("two"))
+
+	if len(m.Files) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(m.Files))
+	}
+	f, ok := m.Lookup(p)
+	if !ok {
+		t.Fatal("Lookup() did not find recorded file")
+	}
+	if f.SHA256 != HashBytes([]byte("two")) {
+		t.Error("entry hash not updated")
+	}
+	if m.AbsPath(f) != p {
+		t.Errorf("AbsPath() = %q, want %q", m.AbsPath(f), p)
+	}
+}
+
+func TestInstallSkills_RecordsManifest(t *testing.T) {
+	testFS := fstest.MapFS{
+		"skills/my-skill/SKILL.md": &fstest.MapFile{
+			Data: []byte("---\nname: my-skill\ndescription: Test\n---\n# Content"),
+		},
+		"skills/my-skill/references/helper.md": &fstest.MapFile{
+			Data: []byte("# Helper"),
+		},
+		"agents/debugger.md": &fstest.MapFile{
+			Data: []byte("# Debugger"),
+		},
+		"commands/project/init.md": &fstest.MapFile{
+			Data: []byte("# Init"),
+		},
+	}
+
+	root := filepath.Join(t.TempDir(), ".claude")
+	m := NewManifest(root)
+	inst := New(testFS, Options{})
+	inst.SetManifest(m)
+
+	if _, err := inst.InstallSkills(filepath.Join(root, "skills"), nil, nil); err != nil {
+		t.Fatalf("InstallSkills() error: %v", err)
+	}
+	if _, err := inst.InstallAgents(filepath.Join(root, "agents"), nil); err != nil {
+		t.Fatalf("InstallAgents() error: %v", err)
+	}
+	if _, err := inst.InstallCommands(filepath.Join(root, "commands")); err != nil {
+		t.Fatalf("InstallCommands() error: %v", err)
+	}
+
+	want := map[string]string{
+		"skills/my-skill/SKILL.md":             KindSkill,
+		"skills/my-skill/references/helper.md": KindSkill,
+		"agents/debugger.md":                   KindAgent,
+		"commands/project/init.md":             KindCommand,
+	}
+	if len(m.Files) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(m.Files), m.Files)
+	}
+	for _, f := range m.Files {
+		if want[f.Path] != f.Kind {
+			t.Errorf("entry %q has kind %q, want %q", f.Path, f.Kind, want[f.Path])
+		}
+	}
+	if f, _ := m.Lookup(filepath.Join(root, "commands", "project", "init.md")); f.Name != "project" {
+		t.Errorf("command name = %q, want %q", f.Name, "project")
+	}
+}
+
+func TestInstallFile_SkippedFileOnlyRecordedWhenIdentical(t *testing.T) {
+	root := t.TempDir()
+	same := filepath.Join(root, "same.md")
+	different := filepath.Join(root, "different.md")
+	if err := os.WriteFile(same, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(different, []byte("user edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManifest(root)
+	inst := &Installer{manifest: m}
+	if _, err := inst.installFile(KindAgent, "same.md", same, []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.installFile(KindAgent, "different.md", different, []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.Lookup(same); !ok {
+		t.Error("identical skipped file should be recorded")
+	}
+	if _, ok := m.Lookup(different); ok {
+		t.Error("user-modified skipped file should not be recorded")
+	}
+}
+
+func TestInstallFile_DryRunDoesNotRecord(t *testing.T) {
+	root := t.TempDir()
+	m := NewManifest(root)
+	inst := &Installer{manifest: m, options: Options{DryRun: true}}
+	if _, err := inst.installFile(KindSkill, "x", filepath.Join(root, "x.md"), []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 0 {
+		t.Errorf("dry run recorded %d files", len(m.Files))
+	}
+}
@@ -0,0 +1,161 @@
+package installer
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Conflict marker labels used by Merge3.
+const (
+	markerLocal    = "<<<<<<< local\n"
+	markerSep      = "=======\n"
+	markerUpstream = ">>>>>>> upstream\n"
+)
+
+// hunk is a change against the base: base[start:end] is replaced by lines.
+type hunk struct {
+	start, end int
+	lines      []string
+	local      bool // true for changes on the local side
+}
+
+// Merge3 performs a line-based three-way merge of local and upstream against
+// their common base. Changes made on only one side are applied; overlapping
+// changes that differ are wrapped in git-style conflict markers. It returns
+// the merged content and the number of conflicting regions.
+func Merge3(base, local, upstream []byte) ([]byte, int) {
+	if bytes.Equal(local, upstream) {
+		return local, 0
+	}
+	if bytes.Equal(base, local) {
+		return upstream, 0
+	}
+	if bytes.Equal(base, upstream) {
+		return local, 0
+	}
+
+	baseLines := splitLines(base)
+	localLines := splitLines(local)
+	upstreamLines := splitLines(upstream)
+
+	hunks := append(
+		hunksFrom(diffLines(baseLines, localLines), localLines, true),
+		hunksFrom(diffLines(baseLines, upstreamLines), upstreamLines, false)...,
+	)
+	sort.SliceStable(hunks, func(a, b int) bool { return hunks[a].start < hunks[b].start })
+
+	var out strings.Builder
+	conflicts := 0
+	cursor := 0
+
+	for idx := 0; idx < len(hunks); {
+		// Group hunks whose base ranges overlap or touch.
+		group := []hunk{hunks[idx]}
+		start, end := hunks[idx].start, hunks[idx].end
+		idx++
+		for idx < len(hunks) && hunks[idx].start <= end {
+			group = append(group, hunks[idx])
+			if hunks[idx].end > end {
+				end = hunks[idx].end
+			}
+			idx++
+		}
+
+		writeLines(&out, baseLines[cursor:start])
+		cursor = end
+
+		var localSide, upstreamSide []hunk
+		for _, h := range group {
+			if h.local {
+				localSide = append(localSide, h)
+			} else {
+				upstreamSide = append(upstreamSide, h)
+			}
+		}
+
+		localVersion := applyHunks(baseLines, start, end, localSide)
+		upstreamVersion := applyHunks(baseLines, start, end, upstreamSide)
+
+		switch {
+		case len(upstreamSide) == 0:
+			writeLines(&out, localVersion)
+		case len(localSide) == 0:
+			writeLines(&out, upstreamVersion)
+		case equalLines(localVersion, upstreamVersion):
+			writeLines(&out, localVersion)
+		default:
+			conflicts++
+			out.WriteString(markerLocal)
+			writeTerminated(&out, localVersion)
+			out.WriteString(markerSep)
+			writeTerminated(&out, upstreamVersion)
+			out.WriteString(markerUpstream)
+		}
+	}
+	writeLines(&out, baseLines[cursor:])
+
+	return []byte(out.String()), conflicts
+}
+
+// hunksFrom collapses an edit script against the base into change hunks.
+func hunksFrom(ops []diffOp, other []string, local bool) []hunk {
+	var hunks []hunk
+	for idx := 0; idx < len(ops); {
+		if ops[idx].kind == opEqual {
+			idx++
+			continue
+		}
+		h := hunk{start: ops[idx].aIdx, end: ops[idx].aIdx, local: local}
+		for idx < len(ops) && ops[idx].kind != opEqual {
+			switch ops[idx].kind {
+			case opDelete:
+				h.end = ops[idx].aIdx + 1
+			case opInsert:
+				h.lines = append(h.lines, other[ops[idx].bIdx])
+			}
+			idx++
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// applyHunks returns base[start:end] with one side's hunks applied.
+func applyHunks(base []string, start, end int, hunks []hunk) []string {
+	var result []string
+	cursor := start
+	for _, h := range hunks {
+		result = append(result, base[cursor:h.start]...)
+		result = append(result, h.lines...)
+		cursor = h.end
+	}
+	return append(result, base[cursor:end]...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+	}
+}
+
+// writeTerminated writes lines, making sure a conflict marker that follows
+// starts on its own line.
+func writeTerminated(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,131 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines_Reconstructs(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", ""},
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc\n", "a\nx\nc\n"},
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\nd\ne\n", "x\nb\ny\nd\nz\nw\n"},
+		{"one\ntwo\nthree", "zero\none\nthree\nfour"},
+	}
+
+	for _, tt := range tests {
+		a, b := splitLines([]byte(tt.a)), splitLines([]byte(tt.b))
+		ops := diffLines(a, b)
+
+		var gotA, gotB strings.Builder
+		for _, op := range ops {
+			switch op.kind {
+			case opEqual:
+				if a[op.aIdx] != b[op.bIdx] {
+					t.Errorf("diff(%q, %q): equal op on differing lines", tt.a, tt.b)
+				}
+				gotA.WriteString(a[op.aIdx])
+				gotB.WriteString(b[op.bIdx])
+			case opDelete:
+				gotA.WriteString(a[op.aIdx])
+			case opInsert:
+				gotB.WriteString(b[op.bIdx])
+			}
+		}
+		if gotA.String() != tt.a || gotB.String() != tt.b {
+			t.Errorf("diff(%q, %q) does not reconstruct inputs: got %q / %q", tt.a, tt.b, gotA.String(), gotB.String())
+		}
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	a := splitLines([]byte("a\nb\nc\na\nb\nb\na\n"))
+	b := splitLines([]byte("c\nb\na\nb\na\nc\n"))
+	edits := 0
+	for _, op := range diffLines(a, b) {
+		if op.kind != opEqual {
+			edits++
+		}
+	}
+	// The classic Myers example has a shortest edit script of length 5.
+	if edits != 5 {
+		t.Errorf("got %d edits, want 5", edits)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := "title\n\none\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name          string
+		local         string
+		upstream      string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:     "only upstream changed",
+			local:    base,
+			upstream: "title\n\none\nTWO\nthree\nfour\nfive\n",
+			want:     "title\n\none\nTWO\nthree\nfour\nfive\n",
+		},
+		{
+			name:     "only local changed",
+			local:    "title\n\none\ntwo\nthree\nfour\nFIVE\n",
+			upstream: base,
+			want:     "title\n\none\ntwo\nthree\nfour\nFIVE\n",
+		},
+		{
+			name:     "non-overlapping changes",
+			local:    "TITLE\n\none\ntwo\nthree\nfour\nfive\n",
+			upstream: "title\n\none\ntwo\nthree\nfour\nfive\nsix\n",
+			want:     "TITLE\n\none\ntwo\nthree\nfour\nfive\nsix\n",
+		},
+		{
+			name:     "identical change on both sides",
+			local:    "title\n\none\n2\nthree\nfour\nfive\n",
+			upstream: "title\n\none\n2\nthree\nfour\nfive\nsix\n",
+			want:     "title\n\none\n2\nthree\nfour\nfive\nsix\n",
+		},
+		{
+			name:          "conflicting change",
+			local:         "title\n\none\nmine\nthree\nfour\nfive\n",
+			upstream:      "title\n\none\ntheirs\nthree\nfour\nfive\n",
+			want:          "title\n\none\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> upstream\nthree\nfour\nfive\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge3([]byte(base), []byte(tt.local), []byte(tt.upstream))
+			if string(got) != tt.want {
+				t.Errorf("Merge3() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMerge3_NoBase(t *testing.T) {
+	got, conflicts := Merge3(nil, []byte("local\n"), []byte("upstream\n"))
+	if conflicts != 1 {
+		t.Fatalf("conflicts = %d, want 1", conflicts)
+	}
+	if !strings.Contains(string(got), "<<<<<<< local\nlocal\n=======\nupstream\n>>>>>>> upstream\n") {
+		t.Errorf("unexpected merge result:\n%s", got)
+	}
+}
+
+func TestMerge3_MissingTrailingNewline(t *testing.T) {
+	got, _ := Merge3([]byte("a\nb"), []byte("a\nx"), []byte("a\ny"))
+	want := "a\n<<<<<<< local\nx\n=======\ny\n>>>>>>> upstream\n"
+	if string(got) != want {
+		t.Errorf("Merge3() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,345 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RegistryIndexVersion is the index format this installer reads.
+const RegistryIndexVersion = 1
+
+// maxIndexSize bounds how much of a registry index is read.
+const maxIndexSize = 16 << 20
+
+// RegistryEntry is one published version of a skill.
+type RegistryEntry struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Languages   []string `json:"languages,omitempty"`
+	URL         string   `json:"url"`    // Archive holding the skill, absolute or relative to the index
+	SHA256      string   `json:"sha256"` // Digest of the archive
+}
+
+// RegistryIndex is a registry's index.json: every version of every skill it
+// publishes.
+type RegistryIndex struct {
+	Version int             `json:"version"`
+	Skills  []RegistryEntry `json:"skills"`
+
+	location string
+}
+
+// LoadRegistry reads the index at location, an http(s) URL or a file path.
+// A directory is taken to hold an index.json. Downloads carry the
+// credentials auth has for the host (nil for none) and follow opts.
+func LoadRegistry(ctx context.Context, location string, auth *Auth, opts HTTPOptions) (*RegistryIndex, error) {
+	var data []byte
+	var err error
+	if isHTTP(location) {
+		data, err = downloadIndex(ctx, location, auth, opts)
+	} else {
+		location = strings.TrimPrefix(location, "file://")
+		if dirExists(location) {
+			location = filepath.Join(location, "index.json")
+		}
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading registry index: %w", err)
+	}
+
+	var idx RegistryIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("parsing registry index %s: %w", RedactURL(location), err)
+	}
+	if idx.Version != RegistryIndexVersion {
+		return nil, fmt.Errorf("registry index %s has format version %d, expected %d", RedactURL(location), idx.Version, RegistryIndexVersion)
+	}
+	for n, e := range idx.Skills {
+		if e.Name == "" || e.Version == "" || e.URL == "" {
+			return nil, fmt.Errorf("registry index %s: skill %d needs a name, version and url", RedactURL(location), n)
+		}
+	}
+	idx.location = location
+	return &idx, nil
+}
+
+// downloadIndex fetches an index over HTTP.
+func downloadIndex(ctx context.Context, location string, auth *Auth, opts HTTPOptions) ([]byte, error) {
+	opts.Progress = nil
+	resp, err := opts.get(ctx, location, auth, nil)
+	if err != nil {
+		return nil, downloadError(location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, downloadError(location, fmt.Errorf("status %d", resp.StatusCode))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, downloadError(location, err)
+	}
+	if len(data) > maxIndexSize {
+		return nil, downloadError(location, fmt.Errorf("index larger than %d bytes", maxIndexSize))
+	}
+	return data, nil
+}
+
+// isHTTP reports whether location is an http(s) URL.
+func isHTTP(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// ArchiveURL returns where e's archive lives: its URL, resolved against the
+// index's location when relative.
+func (idx *RegistryIndex) ArchiveURL(e RegistryEntry) string {
+	if isHTTP(e.URL) || strings.HasPrefix(e.URL, "file://") || filepath.IsAbs(e.URL) {
+		return e.URL
+	}
+	if isHTTP(idx.location) {
+		base, err := url.Parse(idx.location)
+		if err != nil {
+			return e.URL
+		}
+		ref, err := url.Parse(e.URL)
+		if err != nil {
+			return e.URL
+		}
+		return base.ResolveReference(ref).String()
+	}
+	return filepath.Join(filepath.Dir(idx.location), filepath.FromSlash(e.URL))
+}
+
+// Latest returns the newest version of every skill (see preferVersion),
+// sorted by name.
+func (idx *RegistryIndex) Latest() []RegistryEntry {
+	latest := make(map[string]RegistryEntry)
+	for _, e := range idx.Skills {
+		if cur, ok := latest[e.Name]; !ok || preferVersion(e.Version, cur.Version) {
+			latest[e.Name] = e
+		}
+	}
+	entries := make([]RegistryEntry, 0, len(latest))
+	for _, e := range latest {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].Name < entries[b].Name })
+	return entries
+}
+
+// Search returns the newest version of every skill whose name, description
+// or tags contain query, ignoring case. An empty query matches everything.
+func (idx *RegistryIndex) Search(query string) []RegistryEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []RegistryEntry
+	for _, e := range idx.Latest() {
+		if query == "" ||
+			strings.Contains(strings.ToLower(e.Name), query) ||
+			strings.Contains(strings.ToLower(e.Description), query) ||
+			containsFold(e.Tags, query) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// Matches reports whether e passes the --tag and --lang filters.
+func (e RegistryEntry) Matches(tags, languages []string) bool {
+	return matchesFilter(Skill{Tags: e.Tags, Languages: e.Languages}, tags, languages)
+}
+
+// containsFold reports whether any of values contains query, which must be
+// lower case.
+func containsFold(values []string, query string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the entry for name at version, or its newest version (see
+// preferVersion) when version is empty.
+func (idx *RegistryIndex) Find(name, version string) (RegistryEntry, error) {
+	var found RegistryEntry
+	var versions []string
+	for _, e := range idx.Skills {
+		if e.Name != name {
+			continue
+		}
+		versions = append(versions, e.Version)
+		if version != "" {
+			if strings.TrimPrefix(e.Version, "v") == strings.TrimPrefix(version, "v") {
+				return e, nil
+			}
+			continue
+		}
+		if found.Name == "" || preferVersion(e.Version, found.Version) {
+			found = e
+		}
+	}
+	switch {
+	case len(versions) == 0:
+		return RegistryEntry{}, fmt.Errorf("skill %q not found in the registry", name)
+	case found.Name == "":
+		return RegistryEntry{}, fmt.Errorf("skill %q has no version %s (available: %s)", name, version, strings.Join(versions, ", "))
+	}
+	return found, nil
+}
+
+// ParsePackageRef splits "name@version" into its name and version, which is
+// empty when not given.
+func ParsePackageRef(ref string) (string, string) {
+	name, version, _ := strings.Cut(ref, "@")
+	return name, version
+}
+
+// preferVersion reports whether version a should be picked over b when no
+// version is asked for: the higher one, except that a release always beats a
+// pre-release.
+func preferVersion(a, b string) bool {
+	preA, preB := isPrerelease(a), isPrerelease(b)
+	if preA != preB {
+		return preB
+	}
+	return CompareVersions(a, b) > 0
+}
+
+// isPrerelease reports whether a version has a pre-release suffix (-rc.1).
+func isPrerelease(v string) bool {
+	v, _, _ = strings.Cut(v, "+")
+	return strings.Contains(v, "-")
+}
+
+// CompareVersions orders two semantic versions (with or without a leading
+// v), returning -1, 0 or +1. Numeric parts compare as numbers, and a
+// pre-release sorts before its release.
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	coreA, preA, _ := strings.Cut(a, "-")
+	coreB, preB, _ := strings.Cut(b, "-")
+
+	partsA, partsB := strings.Split(coreA, "."), strings.Split(coreB, ".")
+	for n := 0; n < len(partsA) || n < len(partsB); n++ {
+		var pa, pb string
+		if n < len(partsA) {
+			pa = partsA[n]
+		}
+		if n < len(partsB) {
+			pb = partsB[n]
+		}
+		if c := comparePart(pa, pb); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for n := 0; n < len(idsA) && n < len(idsB); n++ {
+		if c := comparePart(idsA[n], idsB[n]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+// comparePart compares version parts numerically when both are numbers and
+// as strings otherwise. A missing part counts as zero.
+func comparePart(a, b string) int {
+	if a == "" {
+		a = "0"
+	}
+	if b == "" {
+		b = "0"
+	}
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// FindSkillDir locates the skill called name in dir, an extracted registry
+// archive: dir itself, dir/<name>, or dir/skills/<name>.
+func FindSkillDir(dir, name string) (string, error) {
+	for _, candidate := range []string{dir, filepath.Join(dir, name), filepath.Join(dir, "skills", name)} {
+		if isSkillDir(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("archive for %s does not contain a SKILL.md", name)
+}
+
+// InstallSkillDir installs the skill in srcDir as destDir/<name>.
+func (i *Installer) InstallSkillDir(srcDir, destDir, name string) ([]string, error) {
+	var results []string
+	skillDest := filepath.Join(destDir, name)
+	result, skip, err := i.unlinkSkillDir(skillDest)
+	if err != nil {
+		return nil, err
+	}
+	if result != "" {
+		results = append(results, result)
+	}
+	if skip {
+		return results, nil
+	}
+
+	err = filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", p, err)
+		}
+		relPath, _ := filepath.Rel(srcDir, p)
+		result, err := i.installFile(KindSkill, name, filepath.Join(skillDest, relPath), content)
+		if err != nil {
+			return err
+		}
+		if result != "" {
+			results = append(results, result)
+		}
+		return nil
+	})
+	return results, err
+}
@@ -0,0 +1,201 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const testIndex = `{
+  "version": 1,
+  "skills": [
+    {"name": "review", "version": "1.2.0", "description": "Reviews pull requests", "tags": ["quality"], "url": "review-1.2.0.tar.gz", "sha256": "aa"},
+    {"name": "review", "version": "1.10.0", "description": "Reviews pull requests", "tags": ["quality"], "url": "review-1.10.0.tar.gz", "sha256": "bb"},
+    {"name": "review", "version": "2.0.0-rc.1", "url": "review-2.0.0-rc.1.tar.gz", "sha256": "cc"},
+    {"name": "debugging", "version": "0.3.0", "description": "Find root causes", "tags": ["Testing"], "url": "https://cdn.example.com/debugging.zip", "sha256": "dd"}
+  ]
+}`
+
+func TestLoadRegistry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/index.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testIndex))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.json"), []byte(testIndex), 0644)
+
+	tests := []struct {
+		location string
+		wantURL  string
+	}{
+		{srv.URL + "/v1/index.json", srv.URL + "/v1/review-1.10.0.tar.gz"},
+		{filepath.Join(dir, "index.json"), filepath.Join(dir, "review-1.10.0.tar.gz")},
+		{dir, filepath.Join(dir, "review-1.10.0.tar.gz")},
+	}
+	for _, tt := range tests {
+		idx, err := LoadRegistry(context.Background(), tt.location, nil, HTTPOptions{})
+		if err != nil {
+			t.Fatalf("LoadRegistry(%s): %v", tt.location, err)
+		}
+		e, err := idx.Find("review", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := idx.ArchiveURL(e); got != tt.wantURL {
+			t.Errorf("%s: archive URL = %s, want %s", tt.location, got, tt.wantURL)
+		}
+		debugging, _ := idx.Find("debugging", "")
+		if got := idx.ArchiveURL(debugging); got != "https://cdn.example.com/debugging.zip" {
+			t.Errorf("%s: absolute archive URL = %s", tt.location, got)
+		}
+	}
+
+	if _, err := LoadRegistry(context.Background(), srv.URL+"/missing.json", nil, HTTPOptions{}); err == nil {
+		t.Error("expected error for missing index")
+	}
+	os.WriteFile(filepath.Join(dir, "v2.json"), []byte(`{"version": 2, "skills": []}`), 0644)
+	if _, err := LoadRegistry(context.Background(), filepath.Join(dir, "v2.json"), nil, HTTPOptions{}); err == nil {
+		t.Error("expected error for unknown index version")
+	}
+}
+
+func TestRegistryIndex_SearchAndFind(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.json"), []byte(testIndex), 0644)
+	idx, err := LoadRegistry(context.Background(), dir, nil, HTTPOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searches := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"debugging@0.3.0", "review@1.10.0"}},
+		{"REVIEW", []string{"review@1.10.0"}},
+		{"root cause", []string{"debugging@0.3.0"}},
+		{"testing", []string{"debugging@0.3.0"}},
+		{"nothing", nil},
+	}
+	for _, tt := range searches {
+		var got []string
+		for _, e := range idx.Search(tt.query) {
+			got = append(got, e.Name+"@"+e.Version)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	finds := []struct {
+		name, version, want string
+	}{
+		{"review", "", "1.10.0"},
+		{"review", "1.2.0", "1.2.0"},
+		{"review", "v2.0.0-rc.1", "2.0.0-rc.1"},
+	}
+	for _, tt := range finds {
+		e, err := idx.Find(tt.name, tt.version)
+		if err != nil || e.Version != tt.want {
+			t.Errorf("Find(%s, %q) = %s, %v; want %s", tt.name, tt.version, e.Version, err, tt.want)
+		}
+	}
+	if _, err := idx.Find("review", "9.9.9"); err == nil {
+		t.Error("expected error for unknown version")
+	}
+	if _, err := idx.Find("missing", ""); err == nil {
+		t.Error("expected error for unknown skill")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.10.0", -1},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"2.0.0-rc.1", "2.0.0", -1},
+		{"2.0.0-rc.2", "2.0.0-rc.10", -1},
+		{"2.0.0-beta", "2.0.0-alpha", 1},
+		{"1.0.0+build.5", "1.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInstallSkillDir(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "review")
+	os.MkdirAll(filepath.Join(archive, "references"), 0755)
+	os.WriteFile(filepath.Join(archive, "SKILL.md"), []byte("---\nname: review\n---\n"), 0644)
+	os.WriteFile(filepath.Join(archive, "references", "checklist.md"), []byte("- tests"), 0644)
+
+	for _, dir := range []string{archive, filepath.Dir(archive)} {
+		if got, err := FindSkillDir(dir, "review"); err != nil || got != archive {
+			t.Errorf("FindSkillDir(%s) = %s, %v", dir, got, err)
+		}
+	}
+
+	root := filepath.Join(t.TempDir(), ".claude")
+	m := NewManifest(root)
+	inst := New(fstest.MapFS{}, Options{})
+	inst.SetManifest(m)
+	if _, err := inst.InstallSkillDir(archive, filepath.Join(root, "skills"), "review"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(root, "skills", "review", "references", "checklist.md")); got != "- tests" {
+		t.Errorf("checklist.md = %q", got)
+	}
+	if got := m.names(KindSkill); len(got) != 1 || got[0] != "review" {
+		t.Errorf("manifest skills = %v", got)
+	}
+}
+
+func TestUpdate_LeavesRegistrySkills(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/alpha/SKILL.md": {Data: []byte("---\nname: alpha\n---\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+
+	added := t.TempDir()
+	os.WriteFile(filepath.Join(added, "SKILL.md"), []byte("---\nname: review\n---\n"), 0644)
+	inst := New(old, Options{})
+	inst.SetManifest(m)
+	if _, err := inst.InstallSkillDir(added, dests.SkillsDir, "review"); err != nil {
+		t.Fatal(err)
+	}
+	m.RecordPackage(ManifestPackage{Name: "review", Version: "1.0.0", SHA256: "aa"})
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New(old, Options{}).Update(m, dests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 || !fileExists(filepath.Join(root, "skills", "review", "SKILL.md")) {
+		t.Errorf("update touched the registry skill: %v", results)
+	}
+
+	// Uninstalling the skill drops its package entry
+	m.Forget("skills/review/SKILL.md")
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Package("review"); ok {
+		t.Error("package entry kept after its files were forgotten")
+	}
+}
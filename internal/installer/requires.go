@@ -0,0 +1,149 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Agent is an agent and the skills and agents its requires: names.
+type Agent struct {
+	Name     string // File name without .md
+	Requires []string
+}
+
+// Dependency is a skill installed only because a skill or agent that is
+// being installed requires it.
+type Dependency struct {
+	Name       string
+	RequiredBy string // e.g. "skill executing-plans" or "agent debugger"
+}
+
+// discoverAgents reads the agents in fsys's agents/ directory. Agents need
+// no frontmatter; one that can't be parsed requires nothing.
+func discoverAgents(fsys fs.FS) []Agent {
+	if fsys == nil {
+		return nil
+	}
+	entries, err := fs.ReadDir(fsys, "agents")
+	if err != nil {
+		return nil
+	}
+	var agents []Agent
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		agent := Agent{Name: strings.TrimSuffix(e.Name(), ".md")}
+		content, err := fs.ReadFile(fsys, path.Join("agents", e.Name()))
+		if err == nil && bytes.HasPrefix(content, []byte("---")) {
+			if root, first, err := parseFrontmatter(content); err == nil && root != nil {
+				var meta Skill
+				if meta.setFields(root, first) == nil {
+					agent.Requires = meta.Requires
+				}
+			}
+		}
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+// resolveRequires adds to selected the skills that they require, following
+// requires: transitively; with installAgents, the agents (which are then all
+// installed) are followed as well. A required name is a skill's directory
+// name or an agent's, and an agent pulls in nothing but its own
+// requirements. It returns selected followed by the skills pulled in, in the
+// order they were reached, and fails on names that are neither a skill nor
+// an agent, on names that are both, and on cycles.
+func resolveRequires(all, selected []Skill, agents []Agent, installAgents bool) ([]Skill, []Dependency, error) {
+	type node struct {
+		kind, name string
+		requires   []string
+	}
+	skills := make(map[string]Skill)
+	for _, s := range all {
+		skills[path.Base(s.DirPath)] = s
+	}
+	agentsByName := make(map[string]Agent)
+	for _, a := range agents {
+		agentsByName[a.Name] = a
+	}
+	lookup := func(name string) (node, error) {
+		s, isSkill := skills[name]
+		a, isAgent := agentsByName[name]
+		switch {
+		case isSkill && isAgent:
+			return node{}, fmt.Errorf("%q, which names both a skill and an agent in the source", name)
+		case isSkill:
+			return node{"skill", name, s.Requires}, nil
+		case isAgent:
+			return node{"agent", name, a.Requires}, nil
+		}
+		return node{}, fmt.Errorf("%q, which is not a skill or agent in the source", name)
+	}
+
+	result := append([]Skill{}, selected...)
+	installed := make(map[string]bool)
+	for _, s := range selected {
+		installed[path.Base(s.DirPath)] = true
+	}
+	var deps []Dependency
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var stack []string
+	var visit func(n node) error
+	visit = func(n node) error {
+		key := n.kind + " " + n.name
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := []string{n.name}
+			for i := len(stack) - 1; i >= 0 && stack[i] != key; i-- {
+				cycle = append([]string{strings.SplitN(stack[i], " ", 2)[1]}, cycle...)
+			}
+			return fmt.Errorf("requires: forms a cycle: %s -> %s", n.name, strings.Join(cycle, " -> "))
+		}
+		state[key] = visiting
+		stack = append(stack, key)
+		for _, name := range n.requires {
+			dep, err := lookup(name)
+			if err != nil {
+				return fmt.Errorf("%s %s requires %w", n.kind, n.name, err)
+			}
+			if dep.kind == "skill" && !installed[name] {
+				installed[name] = true
+				result = append(result, skills[name])
+				deps = append(deps, Dependency{Name: name, RequiredBy: key})
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = visited
+		return nil
+	}
+
+	for _, s := range selected {
+		name := path.Base(s.DirPath)
+		if err := visit(node{"skill", name, skills[name].Requires}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if installAgents {
+		for _, a := range agents {
+			if err := visit(node{"agent", a.Name, a.Requires}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return result, deps, nil
+}
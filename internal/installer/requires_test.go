@@ -0,0 +1,133 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// requiresFS builds skills and agents whose frontmatter is the given
+// requires: list.
+func requiresFS(skills, agents map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, requires := range skills {
+		fsys["skills/"+name+"/SKILL.md"] = &fstest.MapFile{
+			Data: []byte("---\nname: " + name + "\ndescription: Test\ntags: [" + name + "]\nrequires: [" + requires + "]\n---\n"),
+		}
+	}
+	for name, requires := range agents {
+		fsys["agents/"+name+".md"] = &fstest.MapFile{Data: []byte("---\nrequires: [" + requires + "]\n---\n# " + name)}
+	}
+	fsys["agents/plain.md"] = &fstest.MapFile{Data: []byte("# Plain\n\n---\n")}
+	return fsys
+}
+
+func TestInstallSkills_Requires(t *testing.T) {
+	fsys := requiresFS(map[string]string{
+		"executing": "writing, finishing, implementer",
+		"writing":   "",
+		"finishing": "writing",
+		"debugging": "",
+		"unrelated": "",
+	}, map[string]string{
+		"implementer": "",
+		"debugger":    "debugging",
+	})
+
+	inst := New(fsys, Options{})
+	dest := t.TempDir()
+	if _, err := inst.InstallSkills(dest, []string{"executing"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []Dependency{
+		{"writing", "skill executing"},
+		{"finishing", "skill executing"},
+		{"debugging", "agent debugger"},
+	}
+	if got := inst.Dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %v, want %v", got, want)
+	}
+	for _, name := range []string{"executing", "writing", "finishing", "debugging"} {
+		if !isSkillDir(filepath.Join(dest, name)) {
+			t.Errorf("%s not installed", name)
+		}
+	}
+	if dirExists(filepath.Join(dest, "unrelated")) {
+		t.Error("unrelated skill installed")
+	}
+
+	// Without agents, their requirements don't apply
+	inst.SetAgents(fsys, false)
+	if _, err := inst.InstallSkills(t.TempDir(), []string{"executing"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(inst.Dependencies()) != 2 {
+		t.Errorf("dependencies without agents = %v", inst.Dependencies())
+	}
+}
+
+func TestInstallSkills_RequiresErrors(t *testing.T) {
+	tests := []struct {
+		skills, agents map[string]string
+		wantErr        string
+	}{
+		{map[string]string{"a": "b", "b": "c", "c": "a"}, nil, "requires: forms a cycle: a -> b -> c -> a"},
+		{map[string]string{"a": "a"}, nil, "requires: forms a cycle: a -> a"},
+		{map[string]string{"a": "nowhere"}, nil, `skill a requires "nowhere", which is not a skill or agent in the source`},
+		{map[string]string{"a": "both", "both": ""}, map[string]string{"both": ""}, `skill a requires "both", which names both a skill and an agent in the source`},
+	}
+	for _, tt := range tests {
+		inst := New(requiresFS(tt.skills, tt.agents), Options{})
+		_, err := inst.InstallSkills(t.TempDir(), []string{"a"}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: err = %v, want %q", tt.skills, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInstallFromLocal_IgnoresOtherSourcesAgents(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "skills")
+	writeSkill(t, src, "foo", "description: Foo\n")
+
+	// The installer's own agents require names that only they ship
+	embedded := requiresFS(map[string]string{"bar": ""}, map[string]string{"bar-helper": "bar", "loop": "loop"})
+	inst := New(embedded, Options{})
+	dest := t.TempDir()
+	if _, err := inst.InstallFromLocal(src, dest, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if deps := inst.Dependencies(); len(deps) != 0 {
+		t.Errorf("dependencies = %v", deps)
+	}
+	if dirExists(filepath.Join(dest, "bar")) {
+		t.Error("installed a skill of another source")
+	}
+}
+
+func TestLinkFromLocal_Requires(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "skills")
+	writeSkill(t, src, "executing", "description: Executes plans\ntags: [workflow]\nrequires: [writing]\n")
+	writeSkill(t, src, "writing", "description: Writes plans\n")
+	writeSkill(t, src, "other", "description: Something else\n")
+
+	inst := New(fstest.MapFS{}, Options{})
+	dest := t.TempDir()
+	store := NewStore(filepath.Join(t.TempDir(), "store"))
+	if _, err := inst.LinkFromLocal(store, src, dest, []string{"workflow"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dest)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "executing,writing" {
+		t.Errorf("linked %v", names)
+	}
+	if deps := inst.Dependencies(); len(deps) != 1 || deps[0].Name != "writing" {
+		t.Errorf("dependencies = %v", deps)
+	}
+}
@@ -0,0 +1,195 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSkillVersion is published for skills whose frontmatter has no
+// version; the archive digest still tells their revisions apart.
+const DefaultSkillVersion = "0.0.0"
+
+// RegistryServer serves a directory of skills as a registry: a generated
+// /index.json and a tar.gz archive per skill under /archives/. The directory
+// is rescanned whenever the index is requested, so edits show up without a
+// restart.
+type RegistryServer struct {
+	dir string
+
+	mu       sync.Mutex
+	index    []byte
+	archives map[string][]byte // Archive path -> content
+}
+
+// NewRegistryServer scans dir, a directory of skills (or one holding a
+// skills/ directory), and returns a server for it.
+func NewRegistryServer(dir string) (*RegistryServer, error) {
+	s := &RegistryServer{dir: dir}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh rescans the directory and rebuilds the index and archives.
+func (s *RegistryServer) Refresh() error {
+	layout, err := ResolveLayout(s.dir, "")
+	if err != nil {
+		return err
+	}
+	inst := New(os.DirFS(layout.Skills), Options{})
+	skills, err := inst.discoverSkillsIn(".")
+	if err != nil {
+		return err
+	}
+
+	idx := RegistryIndex{Version: RegistryIndexVersion, Skills: []RegistryEntry{}}
+	archives := make(map[string][]byte)
+	for _, skill := range skills {
+		name := path.Base(skill.DirPath)
+		data, err := inst.skillArchive(skill.DirPath, name)
+		if err != nil {
+			return fmt.Errorf("packing %s: %w", name, err)
+		}
+		version := skill.Version
+		if version == "" {
+			version = DefaultSkillVersion
+		}
+		archivePath := "archives/" + name + "-" + version + ".tar.gz"
+		archives["/"+archivePath] = data
+		idx.Skills = append(idx.Skills, RegistryEntry{
+			Name:        name,
+			Version:     version,
+			Description: skill.Description,
+			Tags:        skill.Tags,
+			Languages:   skill.Languages,
+			URL:         archivePath,
+			SHA256:      HashBytes(data),
+		})
+	}
+
+	index, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.index = append(index, '\n')
+	s.archives = archives
+	s.mu.Unlock()
+	return nil
+}
+
+// Skills returns the number of skills currently served.
+func (s *RegistryServer) Skills() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.archives)
+}
+
+// ServeHTTP serves /index.json and the archives it lists. Responses carry a
+// strong ETag of their SHA-256, so clients can revalidate cheaply, and
+// archives also carry their digest in an RFC 3230 Digest header.
+func (s *RegistryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/" || r.URL.Path == "/index.json":
+		if err := s.Refresh(); err != nil {
+			http.Error(w, "scanning skills failed", http.StatusInternalServerError)
+			return
+		}
+		s.mu.Lock()
+		index := s.index
+		s.mu.Unlock()
+		serveContent(w, r, "application/json", index)
+	case strings.HasPrefix(r.URL.Path, "/archives/"):
+		s.mu.Lock()
+		data, ok := s.archives[r.URL.Path]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		sum := sha256.Sum256(data)
+		w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		serveContent(w, r, "application/gzip", data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveContent writes data with an ETag of its digest, answering
+// If-None-Match with 304 Not Modified.
+func serveContent(w http.ResponseWriter, r *http.Request, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+HashBytes(data)+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// skillArchive packs the skill in dirPath into a tar.gz whose entries sit
+// under name/. The archive is reproducible: entries are in walk order and
+// carry no timestamps or owners, so unchanged skills keep their digest.
+func (i *Installer) skillArchive(dirPath, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	err := fs.WalkDir(i.fsys, dirPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dirPath), "/")
+		entryName := path.Join(name, rel)
+		if d.IsDir() {
+			return tw.WriteHeader(&tar.Header{Name: entryName + "/", Mode: 0755, Typeflag: tar.TypeDir})
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := fs.ReadFile(i.fsys, p)
+		if err != nil {
+			return err
+		}
+		mode := int64(0644)
+		if info.Mode().Perm()&0111 != 0 {
+			mode = 0755
+		}
+		hdr := &tar.Header{Name: entryName, Mode: mode, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,104 @@
+package installer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSkill creates dir/name/SKILL.md with the given frontmatter lines.
+func writeSkill(t *testing.T, dir, name, frontmatter string) {
+	t.Helper()
+	os.MkdirAll(filepath.Join(dir, name), 0755)
+	content := "---\nname: " + name + "\n" + frontmatter + "---\n# " + name + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryServer(t *testing.T) {
+	dir := t.TempDir()
+	writeSkill(t, dir, "review", "description: Reviews code\nversion: 1.2.0\ntags: [quality]\n")
+	writeSkill(t, dir, "debugging", "")
+	os.MkdirAll(filepath.Join(dir, "review", "scripts"), 0755)
+	os.WriteFile(filepath.Join(dir, "review", "scripts", "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.MkdirAll(filepath.Join(dir, "not-a-skill"), 0755)
+
+	registry, err := NewRegistryServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(registry)
+	defer srv.Close()
+
+	idx, err := LoadRegistry(context.Background(), srv.URL+"/index.json", nil, HTTPOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Skills) != 2 {
+		t.Fatalf("index lists %+v", idx.Skills)
+	}
+	review, err := idx.Find("review", "")
+	if err != nil || review.Version != "1.2.0" || review.Description != "Reviews code" {
+		t.Errorf("review entry = %+v, %v", review, err)
+	}
+	if debugging, _ := idx.Find("debugging", ""); debugging.Version != DefaultSkillVersion {
+		t.Errorf("unversioned skill published as %s", debugging.Version)
+	}
+
+	// The archive matches its digest and installs like any registry archive
+	c, _ := testCache(t)
+	archiveDir, err := c.FetchURL(context.Background(), idx.ArchiveURL(review), &Verifier{SHA256: review.SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skillDir, err := FindSkillDir(archiveDir, "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(skillDir, "scripts", "run.sh"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("run.sh = %v, %v", info, err)
+	}
+
+	// Unchanged skills keep their digest, and ETags allow revalidation
+	if err := registry.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := LoadRegistry(context.Background(), srv.URL+"/index.json", nil, HTTPOptions{})
+	if e, _ := again.Find("review", ""); e.SHA256 != review.SHA256 {
+		t.Errorf("digest changed on rescan: %s, %s", e.SHA256, review.SHA256)
+	}
+	resp, err := http.Get(idx.ArchiveURL(review))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("Digest") == "" {
+		t.Error("archive served without a Digest header")
+	}
+	req, _ := http.NewRequest(http.MethodGet, idx.ArchiveURL(review), nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("revalidation status = %d", resp.StatusCode)
+	}
+
+	// New skills show up on the next index request
+	writeSkill(t, dir, "planning", "version: 0.1.0\n")
+	if idx, err := LoadRegistry(context.Background(), srv.URL+"/index.json", nil, HTTPOptions{}); err != nil || len(idx.Skills) != 3 {
+		t.Errorf("after adding a skill: %v", err)
+	}
+	if resp, err := http.Get(srv.URL + "/archives/missing-1.0.0.tar.gz"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing archive: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of --from sources.
+const (
+	SourceLocal   = "local"   // A directory on disk, copied as is
+	SourceGit     = "git"     // A git repository, cloned
+	SourceArchive = "archive" // A zip or tar archive, downloaded if remote and extracted
+)
+
+// archiveSuffixes mark a URL as an archive rather than a repository.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar", ".zip", ".tar.zst"}
+
+// knownGitHosts serve git repositories at their plain project URLs.
+var knownGitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// ClassifySource decides how a --from source is fetched and returns its kind
+// along with the source stripped of any explicit prefix. In order:
+//
+//   - a git:: or tar:: prefix forces the kind;
+//   - scp-style addresses (git@host:org/repo) and ssh:// and git:// URLs
+//     are git, as are file:// URLs other than archives;
+//   - http(s) URLs ending in an archive extension are archives, and ones
+//     ending in .git or on a well-known git host are git; any other URL is
+//     probed for git's smart HTTP protocol, with auth's credentials and
+//     opts' timeout, and treated as an archive if it answers without it;
+//   - local bare repositories, and local repositories with an @ref or #sha,
+//     are git; local files are archives; other local paths are copied.
+//
+// A ref (see ParseGitSource) and a //sub/path (see SplitSubdir) should be
+// split off or left in place consistently; ClassifySource ignores them.
+// Cancelling ctx stops a probe.
+func ClassifySource(ctx context.Context, src string, auth *Auth, opts HTTPOptions) (string, string) {
+	switch {
+	case strings.HasPrefix(src, "git::"):
+		return SourceGit, strings.TrimPrefix(src, "git::")
+	case strings.HasPrefix(src, "tar::"):
+		return SourceArchive, strings.TrimPrefix(src, "tar::")
+	}
+
+	location, ref := ParseGitSource(src)
+	scheme, rest, hasScheme := strings.Cut(location, "://")
+	if !hasScheme {
+		if isSCPLike(location) {
+			return SourceGit, src
+		}
+		if isBareRepo(location) || (ref != "" && !dirExists(src) && dirExists(filepath.Join(location, ".git"))) {
+			return SourceGit, src
+		}
+		if info, err := os.Stat(src); err == nil && info.Mode().IsRegular() {
+			return SourceArchive, src
+		}
+		return SourceLocal, src
+	}
+
+	switch strings.ToLower(scheme) {
+	case "ssh", "git", "git+ssh":
+		return SourceGit, src
+	case "file":
+		if hasArchiveSuffix(location) {
+			return SourceArchive, src
+		}
+		return SourceGit, src
+	case "http", "https":
+	default:
+		return SourceArchive, src
+	}
+
+	lower := strings.ToLower(strings.TrimSuffix(location, "/"))
+	if hasArchiveSuffix(lower) {
+		return SourceArchive, src
+	}
+	if strings.HasSuffix(lower, ".git") {
+		return SourceGit, src
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	for _, known := range knownGitHosts {
+		if strings.EqualFold(host, known) {
+			return SourceGit, src
+		}
+	}
+	if probeGitHTTP(ctx, location, auth, opts) {
+		return SourceGit, src
+	}
+	return SourceArchive, src
+}
+
+// hasArchiveSuffix reports whether a URL ends in an archive extension.
+func hasArchiveSuffix(url string) bool {
+	lower := strings.ToLower(strings.TrimSuffix(url, "/"))
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSCPLike reports whether src is an scp-style git address such as
+// git@host:org/repo.git.
+func isSCPLike(src string) bool {
+	colon := strings.Index(src, ":")
+	if colon <= 0 || strings.Contains(src[:colon], "/") {
+		return false
+	}
+	// A drive letter (C:\path) is a local path
+	return !(colon == 1 && len(src) > 2 && (src[2] == '\\' || src[2] == '/'))
+}
+
+// isBareRepo reports whether dir is a bare git repository.
+func isBareRepo(dir string) bool {
+	return fileExists(filepath.Join(dir, "HEAD")) &&
+		dirExists(filepath.Join(dir, "objects")) &&
+		dirExists(filepath.Join(dir, "refs"))
+}
+
+// probeGitHTTP reports whether url answers git's smart HTTP protocol, or
+// asks for credentials it was not given (as private git hosts do, while
+// archive hosts have no reason to). It is a variable so tests can avoid the
+// network.
+var probeGitHTTP = func(ctx context.Context, url string, auth *Auth, opts HTTPOptions) bool {
+	opts.Retries = -1
+	opts.Progress = nil
+	resp, err := opts.get(ctx, strings.TrimSuffix(url, "/")+"/info/refs?service=git-upload-pack", auth, nil)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	return resp.StatusCode == http.StatusOK &&
+		strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-git-upload-pack")
+}
+
+// SourceLayout locates the content of a fetched source.
+type SourceLayout struct {
+	Skills string // Directory whose subdirectories are skills
+	Root   string // Directory that may hold agents/ and commands/; empty if none
+}
+
+// Agents returns the source's agents directory, or "" if it has none.
+func (l SourceLayout) Agents() string {
+	return l.sibling("agents")
+}
+
+// Commands returns the source's commands directory, or "" if it has none.
+func (l SourceLayout) Commands() string {
+	return l.sibling("commands")
+}
+
+func (l SourceLayout) sibling(name string) string {
+	if l.Root == "" || !dirExists(filepath.Join(l.Root, name)) {
+		return ""
+	}
+	return filepath.Join(l.Root, name)
+}
+
+// SplitSubdir splits a source of the form "<source>//<sub/path>" (as in
+// Terraform module sources) into the source and the subdirectory. A ref
+// written after the subdirectory ("repo//sub/path@v1") stays with the source.
+func SplitSubdir(src string) (string, string) {
+	start := 0
+	if i := strings.Index(src, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	i := strings.Index(src[start:], "//")
+	if i < 0 {
+		return src, ""
+	}
+	source, subdir := src[:start+i], src[start+i+2:]
+	if j := strings.IndexAny(subdir, "@#"); j >= 0 {
+		source += subdir[j:]
+		subdir = subdir[:j]
+	}
+	return source, strings.Trim(subdir, "/")
+}
+
+// SplitChecksum removes a sha256=<hex> pin from a source's query string
+// (https://host/skills.tar.gz?sha256=...) and returns the source without it
+// and the pinned digest. Other query parameters are kept.
+func SplitChecksum(src string) (string, string) {
+	q := strings.LastIndex(src, "?")
+	if q < 0 {
+		return src, ""
+	}
+	var sum string
+	var kept []string
+	for _, param := range strings.Split(src[q+1:], "&") {
+		if v, ok := strings.CutPrefix(param, "sha256="); ok {
+			sum = v
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if sum == "" {
+		return src, ""
+	}
+	if len(kept) == 0 {
+		return src[:q], sum
+	}
+	return src[:q] + "?" + strings.Join(kept, "&"), sum
+}
+
+// ResolveLayout finds the skills, agents, and commands in dir, a fetched
+// source, starting from subdir within it. A skills/ directory is preferred;
+// a subdir that is itself named skills has its agents/ and commands/
+// siblings picked up; otherwise the starting directory holds the skills.
+func ResolveLayout(dir, subdir string) (SourceLayout, error) {
+	base := dir
+	if subdir != "" {
+		clean := path.Clean(subdir)
+		if clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+			return SourceLayout{}, fmt.Errorf("invalid subdirectory %q", subdir)
+		}
+		base = filepath.Join(dir, filepath.FromSlash(clean))
+		if !dirExists(base) {
+			return SourceLayout{}, fmt.Errorf("subdirectory %q not found in source", subdir)
+		}
+	}
+
+	switch {
+	case dirExists(filepath.Join(base, "skills")):
+		return SourceLayout{Skills: filepath.Join(base, "skills"), Root: base}, nil
+	case subdir != "" && filepath.Base(base) == "skills":
+		return SourceLayout{Skills: base, Root: filepath.Dir(base)}, nil
+	default:
+		return SourceLayout{Skills: base}, nil
+	}
+}
+
+// stripTopDir returns the directory an extracted archive's content starts
+// in: dir itself, or its only entry when that is a directory wrapping the
+// content (like skills-1.2.0/) rather than a skill of its own.
+func stripTopDir(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	top := filepath.Join(dir, entries[0].Name())
+	if isSkillDir(top) {
+		return dir
+	}
+	return top
+}
@@ -0,0 +1,221 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitSubdir(t *testing.T) {
+	tests := []struct {
+		src, source, subdir string
+	}{
+		{"https://github.com/org/repo", "https://github.com/org/repo", ""},
+		{"https://github.com/org/repo//tooling/ai/skills", "https://github.com/org/repo", "tooling/ai/skills"},
+		{"https://github.com/org/repo@v1.4.0//tooling/ai", "https://github.com/org/repo@v1.4.0", "tooling/ai"},
+		{"https://github.com/org/repo//tooling/ai@v1.4.0", "https://github.com/org/repo@v1.4.0", "tooling/ai"},
+		{"https://github.com/org/repo//tooling/ai#abc1234", "https://github.com/org/repo#abc1234", "tooling/ai"},
+		{"https://example.com/skills.tar.gz//pkg/", "https://example.com/skills.tar.gz", "pkg"},
+		{"./monorepo//tooling/ai/skills", "./monorepo", "tooling/ai/skills"},
+	}
+	for _, tt := range tests {
+		source, subdir := SplitSubdir(tt.src)
+		if source != tt.source || subdir != tt.subdir {
+			t.Errorf("SplitSubdir(%q) = %q, %q; want %q, %q", tt.src, source, subdir, tt.source, tt.subdir)
+		}
+	}
+}
+
+func TestResolveLayout(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{
+		"skills/one/SKILL.md",
+		"agents/helper.md",
+		"tooling/ai/skills/two/SKILL.md",
+		"tooling/ai/commands/review/run.md",
+		"flat/three/SKILL.md",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0755)
+		os.WriteFile(filepath.Join(dir, p), []byte("x"), 0644)
+	}
+
+	tests := []struct {
+		subdir                   string
+		skills, agents, commands string
+	}{
+		{"", "skills", "agents", ""},
+		{"tooling/ai", "tooling/ai/skills", "", "tooling/ai/commands"},
+		{"tooling/ai/skills", "tooling/ai/skills", "", "tooling/ai/commands"},
+		{"flat", "flat", "", ""},
+	}
+	rel := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		r, _ := filepath.Rel(dir, p)
+		return filepath.ToSlash(r)
+	}
+	for _, tt := range tests {
+		layout, err := ResolveLayout(dir, tt.subdir)
+		if err != nil {
+			t.Fatalf("subdir %q: %v", tt.subdir, err)
+		}
+		if got := rel(layout.Skills); got != tt.skills {
+			t.Errorf("subdir %q: skills = %q, want %q", tt.subdir, got, tt.skills)
+		}
+		if got := rel(layout.Agents()); got != tt.agents {
+			t.Errorf("subdir %q: agents = %q, want %q", tt.subdir, got, tt.agents)
+		}
+		if got := rel(layout.Commands()); got != tt.commands {
+			t.Errorf("subdir %q: commands = %q, want %q", tt.subdir, got, tt.commands)
+		}
+	}
+
+	for _, bad := range []string{"missing", "../outside", "tooling/../../outside"} {
+		if _, err := ResolveLayout(dir, bad); err == nil {
+			t.Errorf("subdir %q: expected error", bad)
+		}
+	}
+}
+
+// bareRepo creates a bare repository holding one committed skill and
+// returns its path.
+func bareRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	runGit(t, "", "init", "-q", work)
+	commitSkill(t, work, "v1")
+	runGit(t, work, "tag", "v1")
+	bare := filepath.Join(dir, "skills.git")
+	runGit(t, "", "clone", "-q", "--bare", work, bare)
+	return bare
+}
+
+func TestClassifySource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	bare := bareRepo(t)
+	work := filepath.Join(filepath.Dir(bare), "work")
+	plain := t.TempDir()
+
+	probed := map[string]bool{"https://gitea.example.com/org/skills": true}
+	defer func(orig func(context.Context, string, *Auth, HTTPOptions) bool) { probeGitHTTP = orig }(probeGitHTTP)
+	probeGitHTTP = func(_ context.Context, url string, _ *Auth, _ HTTPOptions) bool { return probed[url] }
+
+	tests := []struct {
+		src, kind, location string
+	}{
+		{bare, SourceGit, bare},
+		{bare + "@v1", SourceGit, bare + "@v1"},
+		{work, SourceLocal, work},
+		{work + "@v1", SourceGit, work + "@v1"},
+		{plain, SourceLocal, plain},
+		{"file://" + bare, SourceGit, "file://" + bare},
+		{"git@bitbucket.org:org/skills.git", SourceGit, "git@bitbucket.org:org/skills.git"},
+		{"ssh://git@git.example.com/org/skills", SourceGit, "ssh://git@git.example.com/org/skills"},
+		{"https://github.com/org/skills", SourceGit, "https://github.com/org/skills"},
+		{"https://github.com/org/skills/archive/refs/tags/v1.tar.gz", SourceArchive, "https://github.com/org/skills/archive/refs/tags/v1.tar.gz"},
+		{"https://git.example.com/org/skills.git@v2", SourceGit, "https://git.example.com/org/skills.git@v2"},
+		{"https://gitea.example.com/org/skills", SourceGit, "https://gitea.example.com/org/skills"},
+		{"https://cdn.example.com/skills", SourceArchive, "https://cdn.example.com/skills"},
+		{"git::https://cdn.example.com/skills", SourceGit, "https://cdn.example.com/skills"},
+		{"tar::https://github.com/org/skills", SourceArchive, "https://github.com/org/skills"},
+	}
+	for _, tt := range tests {
+		kind, location := ClassifySource(context.Background(), tt.src, nil, HTTPOptions{})
+		if kind != tt.kind || location != tt.location {
+			t.Errorf("ClassifySource(%q) = %s, %q; want %s, %q", tt.src, kind, location, tt.kind, tt.location)
+		}
+	}
+}
+
+func TestClassifySource_FetchBareRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	bare := bareRepo(t)
+	c, _ := testCache(t)
+	for _, src := range []string{bare, "file://" + bare + "@v1"} {
+		kind, location := ClassifySource(context.Background(), src, nil, HTTPOptions{})
+		if kind != SourceGit {
+			t.Fatalf("%s classified as %s", src, kind)
+		}
+		repoURL, ref := ParseGitSource(location)
+		dir, _, err := c.FetchGit(context.Background(), repoURL, ref)
+		if err != nil {
+			t.Fatalf("fetching %s: %v", src, err)
+		}
+		if !isSkillDir(filepath.Join(dir, "skills", "one")) {
+			t.Errorf("%s: skill missing from checkout %v", src, listTree(t, dir))
+		}
+	}
+}
+
+func TestProbeGitHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("service") != "git-upload-pack":
+			http.NotFound(w, r)
+		case r.URL.Path == "/org/skills/info/refs":
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		case r.URL.Path == "/private/skills/info/refs" && r.Header.Get("Authorization") == "Bearer secret":
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		case r.URL.Path == "/private/skills/info/refs", r.URL.Path == "/locked/skills/info/refs":
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	auth := &Auth{
+		Hosts:  []HostCredentials{{Host: strings.TrimPrefix(srv.URL, "http://"), TokenEnv: "TOKEN"}},
+		getenv: func(string) string { return "secret" },
+	}
+
+	if !probeGitHTTP(ctx, srv.URL+"/org/skills", nil, HTTPOptions{}) {
+		t.Error("smart HTTP repository not detected")
+	}
+	if !probeGitHTTP(ctx, srv.URL+"/private/skills", auth, HTTPOptions{}) {
+		t.Error("private repository not detected with credentials")
+	}
+	if !probeGitHTTP(ctx, srv.URL+"/locked/skills", nil, HTTPOptions{}) {
+		t.Error("auth challenge taken for an archive")
+	}
+	if probeGitHTTP(ctx, srv.URL+"/skills.bin", nil, HTTPOptions{}) {
+		t.Error("plain URL detected as a repository")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if probeGitHTTP(cancelled, srv.URL+"/org/skills", nil, HTTPOptions{}) {
+		t.Error("probe ran after cancellation")
+	}
+}
+
+func TestSplitChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		src, source, sum string
+	}{
+		{"https://example.com/skills.zip", "https://example.com/skills.zip", ""},
+		{"https://example.com/skills.zip?sha256=" + sum, "https://example.com/skills.zip", sum},
+		{"https://example.com/dl?id=7&sha256=" + sum + "&v=2", "https://example.com/dl?id=7&v=2", sum},
+		{"https://example.com/dl?id=7", "https://example.com/dl?id=7", ""},
+		{"./skills.tar.gz?sha256=" + sum, "./skills.tar.gz", sum},
+	}
+	for _, tt := range tests {
+		source, got := SplitChecksum(tt.src)
+		if source != tt.source || got != tt.sum {
+			t.Errorf("SplitChecksum(%q) = %q, %q; want %q, %q", tt.src, source, got, tt.source, tt.sum)
+		}
+	}
+}
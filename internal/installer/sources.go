@@ -0,0 +1,155 @@
+package installer
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// SkillSource is one of several sources whose skills are merged into a
+// single install.
+type SkillSource struct {
+	Name     string
+	FS       fs.FS    // Filesystem holding the skills
+	Dir      string   // Directory in FS whose subdirectories are skills
+	Agents   fs.FS    // Filesystem holding the source's agents/ directory; nil if it has none
+	Priority int      // Higher wins when sources ship the same skill
+	Include  []string // Skill name patterns to take (path.Match); empty takes all
+	Exclude  []string // Skill name patterns to leave out
+}
+
+// MergedSkill is the skill picked for a name across sources.
+type MergedSkill struct {
+	Skill
+	Source   string   // Source the skill is installed from
+	Priority int      // That source's priority
+	Shadowed []string // Other sources shipping the same skill, in precedence order
+	// RequiredBy is set when the skill is only installed because another
+	// skill or an agent requires it, e.g. "skill executing-plans".
+	RequiredBy string
+
+	fsys fs.FS
+}
+
+// Name returns the name the skill is installed under.
+func (m MergedSkill) Name() string {
+	return path.Base(m.DirPath)
+}
+
+// MergeSources collects the skills of every source that pass its include
+// and exclude patterns, and the skills those require. When several sources
+// ship a skill of the same name, the one with the highest priority wins, and
+// among equal priorities the one listed first; the tag/language filter then
+// applies to the winners. Agents that skills require are picked the same
+// way. The result is sorted by name.
+func MergeSources(sources []SkillSource, tags, languages []string) ([]MergedSkill, error) {
+	order := make([]int, len(sources))
+	for n := range order {
+		order[n] = n
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sources[order[a]].Priority > sources[order[b]].Priority })
+
+	discovered := make([][]Skill, len(sources))
+	var agents []Agent
+	pickedAgents := make(map[string]bool)
+	for _, n := range order {
+		src := sources[n]
+		for _, pattern := range append(append([]string{}, src.Include...), src.Exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("source %s: invalid pattern %q", src.Name, pattern)
+			}
+		}
+
+		skills, err := New(src.FS, Options{}).discoverSkillsIn(src.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", src.Name, err)
+		}
+		discovered[n] = skills
+		for _, agent := range discoverAgents(src.Agents) {
+			if !pickedAgents[agent.Name] {
+				pickedAgents[agent.Name] = true
+				agents = append(agents, agent)
+			}
+		}
+	}
+
+	// Priority decides which source's copy of each name is used; only then
+	// does the tag/language filter apply, so a lower-priority copy never
+	// stands in for a winner the filter leaves out
+	picked := make(map[string]*MergedSkill)
+	var all, selected []Skill
+	for _, n := range order {
+		src := sources[n]
+		for _, skill := range discovered[n] {
+			name := path.Base(skill.DirPath)
+			if !src.takes(name) {
+				continue
+			}
+			if existing, ok := picked[name]; ok {
+				existing.Shadowed = append(existing.Shadowed, src.Name)
+				continue
+			}
+			picked[name] = &MergedSkill{Skill: skill, Source: src.Name, Priority: src.Priority, fsys: src.FS}
+			all = append(all, skill)
+			if matchesFilter(skill, tags, languages) {
+				selected = append(selected, skill)
+			}
+		}
+	}
+
+	// Agents only resolve the skills' requirements; which agents are
+	// installed alongside is up to the caller
+	selected, deps, err := resolveRequires(all, selected, agents, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deps {
+		picked[d.Name].RequiredBy = d.RequiredBy
+	}
+
+	merged := make([]MergedSkill, 0, len(selected))
+	for _, skill := range selected {
+		merged = append(merged, *picked[path.Base(skill.DirPath)])
+	}
+	sort.Slice(merged, func(a, b int) bool { return merged[a].Name() < merged[b].Name() })
+	return merged, nil
+}
+
+// takes reports whether the source's include and exclude patterns let the
+// named skill through.
+func (s SkillSource) takes(name string) bool {
+	for _, pattern := range s.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, pattern := range s.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallMerged copies merged skills, each from its own source, to destDir.
+// Those pulled in by requirements are recorded as the install's
+// dependencies.
+func (i *Installer) InstallMerged(skills []MergedSkill, destDir string) ([]string, error) {
+	var results []string
+	i.deps = nil
+	for _, skill := range skills {
+		if skill.RequiredBy != "" {
+			i.deps = append(i.deps, Dependency{Name: skill.Name(), RequiredBy: skill.RequiredBy})
+		}
+		skillResults, err := i.WithFS(skill.fsys).installSkill(skill.Skill, destDir)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, skillResults...)
+	}
+	return results, nil
+}
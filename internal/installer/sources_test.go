@@ -0,0 +1,176 @@
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMergeSources(t *testing.T) {
+	skill := func(name, tags string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("---\nname: " + name + "\ntags: [" + tags + "]\n---\n")}
+	}
+	embedded := fstest.MapFS{
+		"skills/review/SKILL.md":    skill("review", "quality"),
+		"skills/debugging/SKILL.md": skill("debugging", "testing"),
+		"skills/planning/SKILL.md":  skill("planning", "workflow"),
+	}
+	org := fstest.MapFS{
+		"review/SKILL.md":   skill("review", "quality"),
+		"security/SKILL.md": skill("security", "quality"),
+	}
+	project := fstest.MapFS{
+		"review/SKILL.md":   skill("review", "quality"),
+		"planning/SKILL.md": skill("planning", "workflow"),
+	}
+
+	tests := []struct {
+		name    string
+		sources []SkillSource
+		tags    []string
+		want    map[string]string // skill -> source
+		shadows map[string][]string
+	}{
+		{
+			name: "priority decides",
+			sources: []SkillSource{
+				{Name: "embedded", FS: embedded, Dir: "skills"},
+				{Name: "org", FS: org, Dir: ".", Priority: 10},
+				{Name: "project", FS: project, Dir: ".", Priority: 20},
+			},
+			want:    map[string]string{"review": "project", "debugging": "embedded", "planning": "project", "security": "org"},
+			shadows: map[string][]string{"review": {"org", "embedded"}, "planning": {"embedded"}},
+		},
+		{
+			name: "equal priority keeps list order",
+			sources: []SkillSource{
+				{Name: "org", FS: org, Dir: "."},
+				{Name: "project", FS: project, Dir: "."},
+			},
+			want:    map[string]string{"review": "org", "security": "org", "planning": "project"},
+			shadows: map[string][]string{"review": {"project"}},
+		},
+		{
+			name: "include and exclude",
+			sources: []SkillSource{
+				{Name: "embedded", FS: embedded, Dir: "skills", Include: []string{"d*", "review"}},
+				{Name: "org", FS: org, Dir: ".", Priority: 10, Exclude: []string{"review"}},
+			},
+			want: map[string]string{"review": "embedded", "debugging": "embedded", "security": "org"},
+		},
+		{
+			name: "tag filter",
+			sources: []SkillSource{
+				{Name: "embedded", FS: embedded, Dir: "skills"},
+				{Name: "org", FS: org, Dir: ".", Priority: 10},
+			},
+			tags:    []string{"quality"},
+			want:    map[string]string{"review": "org", "security": "org"},
+			shadows: map[string][]string{"review": {"embedded"}},
+		},
+		{
+			name: "tag filter applies after priority",
+			sources: []SkillSource{
+				{Name: "embedded", FS: embedded, Dir: "skills"},
+				{Name: "team", FS: fstest.MapFS{"debugging/SKILL.md": skill("debugging", "workflow")}, Dir: ".", Priority: 20},
+			},
+			tags: []string{"testing", "quality"},
+			want: map[string]string{"review": "embedded"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeSources(tt.sources, tt.tags, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(merged) != len(tt.want) {
+				t.Fatalf("merged %d skills, want %d: %+v", len(merged), len(tt.want), merged)
+			}
+			for _, m := range merged {
+				if m.Source != tt.want[m.Name()] {
+					t.Errorf("%s from %s, want %s", m.Name(), m.Source, tt.want[m.Name()])
+				}
+				if got, want := m.Shadowed, tt.shadows[m.Name()]; len(got) != len(want) || (len(got) > 0 && got[0] != want[0]) {
+					t.Errorf("%s shadows %v, want %v", m.Name(), got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := MergeSources([]SkillSource{{Name: "bad", FS: org, Dir: ".", Include: []string{"["}}}, nil, nil); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
+func TestInstallMerged(t *testing.T) {
+	embedded := fstest.MapFS{
+		"skills/review/SKILL.md":         {Data: []byte("---\nname: review\n---\nembedded\n")},
+		"skills/debugging/SKILL.md":      {Data: []byte("---\nname: debugging\n---\n")},
+		"skills/debugging/scripts/go.sh": {Data: []byte("#!/bin/sh\n")},
+	}
+	org := fstest.MapFS{
+		"review/SKILL.md": {Data: []byte("---\nname: review\n---\norg\n")},
+	}
+	merged, err := MergeSources([]SkillSource{
+		{Name: "embedded", FS: embedded, Dir: "skills"},
+		{Name: "org", FS: org, Dir: ".", Priority: 1},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "skills")
+	if _, err := New(embedded, Options{}).InstallMerged(merged, dest); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(dest, "review", "SKILL.md")); got != "---\nname: review\n---\norg\n" {
+		t.Errorf("review/SKILL.md = %q", got)
+	}
+	if !fileExists(filepath.Join(dest, "debugging", "scripts", "go.sh")) {
+		t.Errorf("debugging not installed: %v", listTree(t, dest))
+	}
+}
+
+func TestMergeSources_Requires(t *testing.T) {
+	embedded := fstest.MapFS{
+		"skills/planning/SKILL.md":   {Data: []byte("---\nname: planning\ntags: [docs]\n---\nembedded\n")},
+		"agents/reviewer.md":         {Data: []byte("---\nrequires: [checklists]\n---\n")},
+		"skills/checklists/SKILL.md": {Data: []byte("---\nname: checklists\ntags: [docs]\n---\n")},
+	}
+	org := fstest.MapFS{
+		"executing/SKILL.md": {Data: []byte("---\nname: executing\ntags: [workflow]\nrequires: [planning, reviewer]\n---\n")},
+		"planning/SKILL.md":  {Data: []byte("---\nname: planning\ntags: [docs]\n---\norg\n")},
+	}
+	sources := []SkillSource{
+		{Name: "embedded", FS: embedded, Dir: "skills", Agents: embedded},
+		{Name: "org", FS: org, Dir: ".", Priority: 1},
+	}
+	merged, err := MergeSources(sources, []string{"workflow"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range merged {
+		got = append(got, m.Name()+" from "+m.Source+" "+m.RequiredBy)
+	}
+	want := []string{"checklists from embedded agent reviewer", "executing from org ", "planning from org skill executing"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("merged:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	inst := New(embedded, Options{})
+	if _, err := inst.InstallMerged(merged, filepath.Join(t.TempDir(), "skills")); err != nil {
+		t.Fatal(err)
+	}
+	if deps := inst.Dependencies(); len(deps) != 2 || deps[0] != (Dependency{"checklists", "agent reviewer"}) {
+		t.Errorf("dependencies = %v", deps)
+	}
+
+	// Without the embedded agents, the agent can't be found
+	sources[0].Agents = nil
+	if _, err := MergeSources(sources, []string{"workflow"}, nil); err == nil || !strings.Contains(err.Error(), `requires "reviewer"`) {
+		t.Errorf("missing agent: %v", err)
+	}
+}
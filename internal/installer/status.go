@@ -0,0 +1,369 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Install states reported by Status, from most to least severe.
+const (
+	StatusMissing  = "missing"    // Installed but deleted from disk
+	StatusModified = "modified"   // Changed locally since install
+	StatusOutdated = "outdated"   // Untouched, but the installer ships a newer version
+	StatusUpToDate = "up-to-date" // Matches what the installer ships
+	StatusUnknown  = "unknown"    // Not installed by skill-installer (user-authored)
+)
+
+var statusRank = map[string]int{
+	StatusMissing:  4,
+	StatusModified: 3,
+	StatusOutdated: 2,
+	StatusUpToDate: 1,
+	StatusUnknown:  0,
+}
+
+// FileStatus is the state of a single installed file.
+type FileStatus struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// ItemStatus is the state of an installed skill, agent or command. Its status
+// is the most severe status of its files.
+type ItemStatus struct {
+	Kind   string       `json:"kind"`
+	Name   string       `json:"name"`
+	Status string       `json:"status"`
+	Linked bool         `json:"linked,omitempty"` // Installed as a symlink into the store
+	Files  []FileStatus `json:"files,omitempty"`
+}
+
+// Status compares what is on disk with the manifest and the installer's
+// content. Installs from another source are only compared with the
+// manifest, so nothing in them is outdated. Skills, agents and commands
+// found in the destinations that the manifest does not own are reported as
+// unknown.
+func (i *Installer) Status(m *Manifest, dests UpdateTargets) ([]ItemStatus, error) {
+	var upstream []upstreamFile
+	if m.FromEmbedded() {
+		var err error
+		if upstream, err = i.upstreamFiles(m, dests); err != nil {
+			return nil, err
+		}
+	}
+	upstreamByPath := make(map[string]upstreamFile)
+	for _, uf := range upstream {
+		upstreamByPath[uf.path] = uf
+	}
+
+	items := make(map[string]*ItemStatus)
+	add := func(kind, name, filePath, status string) {
+		key := kind + "\x00" + name
+		item, ok := items[key]
+		if !ok {
+			item = &ItemStatus{Kind: kind, Name: name, Status: status}
+			items[key] = item
+		}
+		item.Files = append(item.Files, FileStatus{Path: filePath, Status: status})
+		if statusRank[status] > statusRank[item.Status] {
+			item.Status = status
+		}
+	}
+
+	for _, f := range m.Files {
+		target := m.AbsPath(f)
+		uf, shipped := upstreamByPath[target]
+		delete(upstreamByPath, target)
+
+		current, err := HashFile(target)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			add(f.Kind, f.Name, target, StatusMissing)
+		case err != nil:
+			return nil, fmt.Errorf("reading %s: %w", target, err)
+		case current != f.SHA256:
+			add(f.Kind, f.Name, target, StatusModified)
+		case !shipped && m.FromEmbedded() && i.covers(m, f, dests), shipped && HashBytes(uf.content) != f.SHA256:
+			add(f.Kind, f.Name, target, StatusOutdated)
+		default:
+			add(f.Kind, f.Name, target, StatusUpToDate)
+		}
+	}
+
+	if err := i.linkStatus(m, add); err != nil {
+		return nil, err
+	}
+	for _, l := range m.Links {
+		items[KindSkill+"\x00"+l.Name].Linked = true
+	}
+
+	// New upstream files inside installed items
+	for _, uf := range upstreamByPath {
+		if fileExists(uf.path) {
+			continue
+		}
+		add(uf.kind, uf.name, uf.path, StatusOutdated)
+	}
+
+	unknown, err := unknownItems(m, dests)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range unknown {
+		if _, owned := items[u.Kind+"\x00"+u.Name]; !owned {
+			items[u.Kind+"\x00"+u.Name] = &ItemStatus{Kind: u.Kind, Name: u.Name, Status: StatusUnknown}
+		}
+	}
+
+	result := make([]ItemStatus, 0, len(items))
+	for _, item := range items {
+		sort.Slice(item.Files, func(a, b int) bool { return item.Files[a].Path < item.Files[b].Path })
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Kind != result[b].Kind {
+			return kindOrder(result[a].Kind) < kindOrder(result[b].Kind)
+		}
+		return result[a].Name < result[b].Name
+	})
+	return result, nil
+}
+
+// linkStatus reports on skills installed as links into the store. A link
+// pointing somewhere else (or replaced by a directory) counts as modified, a
+// dangling one as missing, and one into an older store version as outdated.
+func (i *Installer) linkStatus(m *Manifest, add func(kind, name, filePath, status string)) error {
+	if len(m.Links) == 0 {
+		return nil
+	}
+	digest := ""
+	if m.FromEmbedded() {
+		var err error
+		if digest, err = contentDigest(i.fsys, "skills"); err != nil {
+			return err
+		}
+	}
+
+	for _, l := range m.Links {
+		linkPath := m.LinkPath(l)
+		info, err := os.Lstat(linkPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			add(KindSkill, l.Name, linkPath, StatusMissing)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", linkPath, err)
+		}
+
+		current, _ := os.Readlink(linkPath)
+		version := filepath.Base(filepath.Dir(l.Target))
+		switch {
+		case info.Mode()&fs.ModeSymlink == 0, current != l.Target:
+			add(KindSkill, l.Name, linkPath, StatusModified)
+		case !isSkillDir(l.Target):
+			add(KindSkill, l.Name, linkPath, StatusMissing)
+		case digest != "" && !strings.HasSuffix(version, digest):
+			add(KindSkill, l.Name, linkPath, StatusOutdated)
+		default:
+			add(KindSkill, l.Name, linkPath, StatusUpToDate)
+		}
+	}
+	return nil
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case KindSkill:
+		return 0
+	case KindAgent:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// unknownItems lists skills, agents and commands in the destinations that
+// the manifest does not own.
+func unknownItems(m *Manifest, dests UpdateTargets) ([]ItemStatus, error) {
+	owned := make(map[string]bool)
+	for _, f := range m.Files {
+		owned[f.Kind+"\x00"+f.Name] = true
+	}
+	for _, l := range m.Links {
+		owned[KindSkill+"\x00"+l.Name] = true
+	}
+
+	var items []ItemStatus
+	scan := func(kind, dir string, keep func(os.DirEntry) bool) error {
+		if dir == "" {
+			return nil
+		}
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") || !keep(e) || owned[kind+"\x00"+e.Name()] {
+				continue
+			}
+			items = append(items, ItemStatus{Kind: kind, Name: e.Name(), Status: StatusUnknown})
+		}
+		return nil
+	}
+
+	if err := scan(KindSkill, dests.SkillsDir, func(e os.DirEntry) bool {
+		return isSkillDir(filepath.Join(dests.SkillsDir, e.Name()))
+	}); err != nil {
+		return nil, err
+	}
+	if err := scan(KindAgent, dests.AgentsDir, func(e os.DirEntry) bool {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			return false
+		}
+		// Agents sharing a directory with other files (Copilot) are
+		// recognised by their renamed suffix.
+		return dests.AgentName == nil || strings.HasSuffix(e.Name(), dests.AgentName(".md"))
+	}); err != nil {
+		return nil, err
+	}
+	if err := scan(KindCommand, dests.CommandsDir, func(os.DirEntry) bool { return true }); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// isSkillDir reports whether dir holds a SKILL.md (or skill.md).
+func isSkillDir(dir string) bool {
+	return fileExists(filepath.Join(dir, "SKILL.md")) || fileExists(filepath.Join(dir, "skill.md"))
+}
+
+// DiffSkill returns a unified diff from the installed copy of a skill in
+// skillsDir to the installer's version. Installs from another source are
+// diffed against the content originally installed, as recorded in m, since
+// the installer does not ship it. Files present on only one side are diffed
+// against an empty file. It returns an empty string when they match.
+func (i *Installer) DiffSkill(m *Manifest, skillsDir, name string) (string, error) {
+	installedDir := filepath.Join(skillsDir, name)
+
+	label, missing := "embedded", "shipped by the installer"
+	var reference map[string][]byte
+	var err error
+	if m.FromEmbedded() {
+		reference, err = i.skillFiles(name)
+	} else {
+		label, missing = "original", "recorded as installed"
+		reference, err = recordedSkillFiles(m, installedDir, name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rels := make(map[string]bool)
+	for rel := range reference {
+		rels[rel] = true
+	}
+	// A linked skill is walked in the store it points into
+	walkDir := installedDir
+	if resolved, err := filepath.EvalSymlinks(installedDir); err == nil {
+		walkDir = resolved
+	}
+	err = filepath.WalkDir(walkDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(walkDir, p)
+		rels[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("reading %s: %w", installedDir, err)
+	}
+	if len(rels) == 0 {
+		return "", fmt.Errorf("skill %q is neither installed in %s nor %s", name, skillsDir, missing)
+	}
+
+	sorted := make([]string, 0, len(rels))
+	for rel := range rels {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	var out strings.Builder
+	for _, rel := range sorted {
+		installed, err := os.ReadFile(filepath.Join(installedDir, filepath.FromSlash(rel)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		out.WriteString(UnifiedDiff(path.Join("installed", name, rel), path.Join(label, name, rel), installed, reference[rel]))
+	}
+	return out.String(), nil
+}
+
+// skillFiles reads the installer's files for a skill, keyed by their path
+// within the skill directory.
+func (i *Installer) skillFiles(name string) (map[string][]byte, error) {
+	dir := path.Join("skills", name)
+	paths, _ := i.listDirFiles(dir)
+	files := make(map[string][]byte)
+	for _, p := range paths {
+		data, err := fs.ReadFile(i.fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(p, dir+"/")] = data
+	}
+	return files, nil
+}
+
+// recordedSkillFiles returns the content originally installed for a skill
+// in dir, keyed by path within it: the files m recorded, or for a linked
+// skill the store version it was linked to.
+func recordedSkillFiles(m *Manifest, dir, name string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, l := range m.Links {
+		if l.Name != name {
+			continue
+		}
+		err := filepath.WalkDir(l.Target, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(l.Target, p)
+			files[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", l.Target, err)
+		}
+		return files, nil
+	}
+
+	for _, f := range m.Files {
+		if f.Kind != KindSkill || f.Name != name {
+			continue
+		}
+		data, err := m.Object(f.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("reading the installed content of %s: %w", m.AbsPath(f), err)
+		}
+		rel, err := filepath.Rel(dir, m.AbsPath(f))
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.ToSlash(rel)] = data
+	}
+	return files, nil
+}
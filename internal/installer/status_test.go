@@ -0,0 +1,209 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatus(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/fresh/SKILL.md":    {Data: []byte("---\nname: fresh\n---\n")},
+		"skills/edited/SKILL.md":   {Data: []byte("---\nname: edited\n---\n")},
+		"skills/stale/SKILL.md":    {Data: []byte("---\nname: stale\n---\nv1\n")},
+		"skills/deleted/SKILL.md":  {Data: []byte("---\nname: deleted\n---\n")},
+		"skills/growing/SKILL.md":  {Data: []byte("---\nname: growing\n---\n")},
+		"agents/debugger.md":       {Data: []byte("# Debugger\n")},
+		"commands/project/init.md": {Data: []byte("# Init\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+
+	os.WriteFile(filepath.Join(root, "skills", "edited", "SKILL.md"), []byte("local change"), 0644)
+	os.Remove(filepath.Join(root, "skills", "deleted", "SKILL.md"))
+	os.MkdirAll(filepath.Join(root, "skills", "mine"), 0755)
+	os.WriteFile(filepath.Join(root, "skills", "mine", "SKILL.md"), []byte("---\nname: mine\n---\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "skills", "not-a-skill"), 0755)
+	os.WriteFile(filepath.Join(root, "agents", "custom.md"), []byte("# Custom\n"), 0644)
+
+	current := fstest.MapFS{
+		"skills/fresh/SKILL.md":     {Data: []byte("---\nname: fresh\n---\n")},
+		"skills/edited/SKILL.md":    {Data: []byte("---\nname: edited\n---\n")},
+		"skills/stale/SKILL.md":     {Data: []byte("---\nname: stale\n---\nv2\n")},
+		"skills/deleted/SKILL.md":   {Data: []byte("---\nname: deleted\n---\n")},
+		"skills/growing/SKILL.md":   {Data: []byte("---\nname: growing\n---\n")},
+		"skills/growing/example.md": {Data: []byte("new file\n")},
+		"agents/debugger.md":        {Data: []byte("# Debugger\n")},
+		"commands/project/init.md":  {Data: []byte("# Init\n")},
+	}
+
+	items, err := New(current, Options{}).Status(m, dests)
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, item := range items {
+		got[item.Kind+"/"+item.Name] = item.Status
+	}
+	want := map[string]string{
+		"skill/fresh":       StatusUpToDate,
+		"skill/edited":      StatusModified,
+		"skill/stale":       StatusOutdated,
+		"skill/deleted":     StatusMissing,
+		"skill/growing":     StatusOutdated,
+		"skill/mine":        StatusUnknown,
+		"agent/debugger.md": StatusUpToDate,
+		"agent/custom.md":   StatusUnknown,
+		"command/project":   StatusUpToDate,
+	}
+	for key, status := range want {
+		if got[key] != status {
+			t.Errorf("%s: status %q, want %q", key, got[key], status)
+		}
+	}
+	if _, ok := got["skill/not-a-skill"]; ok {
+		t.Error("directory without SKILL.md reported as a skill")
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d items, want %d: %v", len(got), len(want), got)
+	}
+	if items[0].Kind != KindSkill || items[len(items)-1].Kind != KindCommand {
+		t.Error("items not ordered skills, agents, commands")
+	}
+}
+
+func TestStatus_FromSource(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "skills")
+	writeSkill(t, src, "kept", "description: Kept\n")
+	writeSkill(t, src, "edited", "description: Edited\n")
+	writeSkill(t, src, "deleted", "description: Deleted\n")
+
+	root := filepath.Join(t.TempDir(), ".claude")
+	dests := UpdateTargets{SkillsDir: filepath.Join(root, "skills")}
+	m := NewManifest(root)
+	m.Source = src
+	// The embedded content ships a different version of one skill
+	embedded := fstest.MapFS{"skills/kept/SKILL.md": {Data: []byte("---\nname: kept\n---\nembedded\n")}}
+	inst := New(embedded, Options{})
+	inst.SetManifest(m)
+	if _, err := inst.InstallFromLocal(src, dests.SkillsDir, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := filepath.Join(dests.SkillsDir, "edited", "SKILL.md")
+	os.WriteFile(edited, []byte("---\nname: edited\n---\nlocal\n"), 0644)
+	os.Remove(filepath.Join(dests.SkillsDir, "deleted", "SKILL.md"))
+
+	items, err := inst.Status(m, dests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, item := range items {
+		got[item.Name] = item.Status
+	}
+	want := map[string]string{"kept": StatusUpToDate, "edited": StatusModified, "deleted": StatusMissing}
+	if len(got) != len(want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: status %q, want %q", name, got[name], status)
+		}
+	}
+
+	// Diffs compare with what was installed, not the embedded content
+	if diff, err := inst.DiffSkill(m, dests.SkillsDir, "kept"); err != nil || diff != "" {
+		t.Errorf("diff of an untouched skill = %q, %v", diff, err)
+	}
+	diff, err := inst.DiffSkill(m, dests.SkillsDir, "edited")
+	if err != nil || !strings.Contains(diff, "--- installed/edited/SKILL.md\n+++ original/edited/SKILL.md\n") || !strings.Contains(diff, "-local\n") {
+		t.Errorf("diff of an edited skill = %q, %v", diff, err)
+	}
+}
+
+func TestStatus_CopilotAgentsShareDirectory(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "copilot-instructions.md"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(root, "mine.agent.md"), []byte("x"), 0644)
+
+	dests := UpdateTargets{AgentsDir: root, AgentName: CopilotAgentName}
+	items, err := New(fstest.MapFS{}, Options{}).Status(NewManifest(root), dests)
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "mine.agent.md" {
+		t.Errorf("items = %+v, want only mine.agent.md", items)
+	}
+}
+
+func TestDiffSkill(t *testing.T) {
+	fsys := fstest.MapFS{
+		"skills/s/SKILL.md":      {Data: []byte("one\ntwo\nthree\n")},
+		"skills/s/refs/added.md": {Data: []byte("added\n")},
+	}
+	skillsDir := t.TempDir()
+	os.MkdirAll(filepath.Join(skillsDir, "s"), 0755)
+	os.WriteFile(filepath.Join(skillsDir, "s", "SKILL.md"), []byte("one\n2\nthree\n"), 0644)
+	os.WriteFile(filepath.Join(skillsDir, "s", "local.md"), []byte("local\n"), 0644)
+
+	diff, err := New(fsys, Options{}).DiffSkill(NewManifest(skillsDir), skillsDir, "s")
+	if err != nil {
+		t.Fatalf("DiffSkill() error: %v", err)
+	}
+
+	for _, want := range []string{
+		"--- installed/s/SKILL.md\n+++ embedded/s/SKILL.md\n@@ -1,3 +1,3 @@\n one\n-2\n+two\n three\n",
+		"--- installed/s/local.md\n+++ embedded/s/local.md\n@@ -1 +0,0 @@\n-local\n",
+		"--- installed/s/refs/added.md\n+++ embedded/s/refs/added.md\n@@ -0,0 +1 @@\n+added\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing:\n%s\ngot:\n%s", want, diff)
+		}
+	}
+
+	if _, err := New(fsys, Options{}).DiffSkill(NewManifest(skillsDir), skillsDir, "nope"); err == nil {
+		t.Error("expected error for unknown skill")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if d := UnifiedDiff("a", "b", []byte("same\n"), []byte("same\n")); d != "" {
+		t.Errorf("identical inputs produced a diff: %q", d)
+	}
+
+	var a, b strings.Builder
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i) + "\n"
+		a.WriteString(line)
+		if i == 2 || i == 18 {
+			line = "changed\n"
+		}
+		b.WriteString(line)
+	}
+	d := UnifiedDiff("a", "b", []byte(a.String()), []byte(b.String()))
+	if strings.Count(d, "@@ -") != 2 {
+		t.Errorf("expected two hunks for distant changes, got:\n%s", d)
+	}
+	if !strings.Contains(d, "@@ -1,5 +1,5 @@") || !strings.Contains(d, "@@ -15,6 +15,6 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", d)
+	}
+
+	d = UnifiedDiff("a", "b", []byte("a\nb"), []byte("a\nc"))
+	if !strings.Contains(d, "-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n") {
+		t.Errorf("missing no-newline marker:\n%s", d)
+	}
+
+	if d := UnifiedDiff("a", "b", []byte("x\x00"), []byte("y")); !strings.HasPrefix(d, "Binary files") {
+		t.Errorf("binary diff = %q", d)
+	}
+}
@@ -0,0 +1,276 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stageDirPattern names the temporary directory a transaction stages into.
+const stageDirPattern = ".skill-installer-stage-*"
+
+// Transaction stages file writes in a temporary directory next to the
+// destination and moves them into place together on Commit. If anything
+// fails, every change made by the transaction is undone, so an install either
+// fully succeeds or leaves the tree as it was.
+type Transaction struct {
+	dir         string // Where the staging directory is created
+	stage       string // Staging directory, created on the first write
+	files       []stagedFile
+	staged      map[string]int // Target path -> index in files
+	applied     []appliedFile
+	createdDirs []string
+	done        bool
+}
+
+// stagedFile is a file waiting in the staging directory, or a path to remove
+// when remove is set.
+type stagedFile struct {
+	target string
+	staged string
+	remove bool
+}
+
+// appliedFile is a file moved into place by Commit. backup holds the previous
+// content, or is empty if the target did not exist.
+type appliedFile struct {
+	target string
+	backup string
+}
+
+// NewTransaction starts a transaction staging into dir, which should be on
+// the same filesystem as the files being written (e.g. .claude).
+func NewTransaction(dir string) *Transaction {
+	return &Transaction{dir: dir, staged: make(map[string]int)}
+}
+
+// Stage writes content to the staging directory, to be moved to target on
+// Commit. Staging the same target again replaces the earlier content.
+func (t *Transaction) Stage(target string, content []byte) error {
+	if t.done {
+		return errors.New("transaction already finished")
+	}
+	if err := t.ensureStage(); err != nil {
+		return err
+	}
+
+	idx, ok := t.staged[target]
+	if !ok {
+		idx = len(t.files)
+		t.files = append(t.files, stagedFile{
+			target: target,
+			staged: filepath.Join(t.stage, fmt.Sprintf("%d.new", idx)),
+		})
+		t.staged[target] = idx
+	}
+	if err := os.WriteFile(t.files[idx].staged, content, 0644); err != nil {
+		return fmt.Errorf("staging %s: %w", target, err)
+	}
+	return nil
+}
+
+// Link schedules a symlink to dest to be created at target on Commit,
+// replacing whatever target holds then.
+func (t *Transaction) Link(target, dest string) error {
+	if t.done {
+		return errors.New("transaction already finished")
+	}
+	if err := t.ensureStage(); err != nil {
+		return err
+	}
+
+	idx := len(t.files)
+	staged := filepath.Join(t.stage, fmt.Sprintf("%d.new", idx))
+	if err := os.Symlink(dest, staged); err != nil {
+		return fmt.Errorf("staging link %s: %w", target, err)
+	}
+	t.files = append(t.files, stagedFile{target: target, staged: staged})
+	// Later writes to target start over rather than writing through the link
+	delete(t.staged, target)
+	return nil
+}
+
+// Remove schedules target (a file, directory or symlink) for removal on
+// Commit. Removals and writes are applied in the order they were staged.
+func (t *Transaction) Remove(target string) error {
+	if t.done {
+		return errors.New("transaction already finished")
+	}
+	if err := t.ensureStage(); err != nil {
+		return err
+	}
+	t.files = append(t.files, stagedFile{target: target, remove: true})
+	return nil
+}
+
+// removes reports whether p, or a directory containing it, is scheduled for
+// removal.
+func (t *Transaction) removes(p string) bool {
+	for _, f := range t.files {
+		if !f.remove {
+			continue
+		}
+		if rel, err := filepath.Rel(f.target, p); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// Commit moves every staged file into place, then runs finish (if not nil),
+// e.g. to save the install manifest. If a move or finish fails, everything
+// the transaction changed is restored and the error is returned.
+func (t *Transaction) Commit(finish func() error) error {
+	if t.done {
+		return errors.New("transaction already finished")
+	}
+
+	for idx, f := range t.files {
+		if err := t.apply(idx, f); err != nil {
+			return t.abort(err)
+		}
+	}
+	if finish != nil {
+		if err := finish(); err != nil {
+			return t.abort(err)
+		}
+	}
+
+	t.done = true
+	return t.cleanup()
+}
+
+// apply moves one staged file over its target, keeping the old content.
+func (t *Transaction) apply(idx int, f stagedFile) error {
+	if f.remove {
+		if _, err := os.Lstat(f.target); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		backup := filepath.Join(t.stage, fmt.Sprintf("%d.old", idx))
+		if err := os.Rename(f.target, backup); err != nil {
+			return fmt.Errorf("removing %s: %w", f.target, err)
+		}
+		t.applied = append(t.applied, appliedFile{target: f.target, backup: backup})
+		return nil
+	}
+
+	if err := t.mkdirAll(filepath.Dir(f.target)); err != nil {
+		return err
+	}
+
+	applied := appliedFile{target: f.target}
+	if _, err := os.Lstat(f.target); err == nil {
+		applied.backup = filepath.Join(t.stage, fmt.Sprintf("%d.old", idx))
+		if err := os.Rename(f.target, applied.backup); err != nil {
+			return fmt.Errorf("replacing %s: %w", f.target, err)
+		}
+	}
+	t.applied = append(t.applied, applied)
+
+	if err := os.Rename(f.staged, f.target); err != nil {
+		return fmt.Errorf("writing %s: %w", f.target, err)
+	}
+	return nil
+}
+
+// Rollback undoes the transaction, discarding staged files and restoring
+// anything already moved into place. It is a no-op once the transaction has
+// been committed or rolled back, so it is safe to defer.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	var errs []error
+	for idx := len(t.applied) - 1; idx >= 0; idx-- {
+		a := t.applied[idx]
+		if a.backup == "" {
+			if err := os.Remove(a.target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.RemoveAll(a.target); err != nil {
+			errs = append(errs, err)
+		}
+		if err := os.Rename(a.backup, a.target); err != nil {
+			errs = append(errs, fmt.Errorf("restoring %s: %w", a.target, err))
+		}
+	}
+	t.applied = nil
+
+	if err := t.cleanup(); err != nil {
+		errs = append(errs, err)
+	}
+	// Remove directories this transaction created, deepest first. Ones that
+	// still hold other files, or were since replaced by a restored symlink,
+	// are left alone.
+	for idx := len(t.createdDirs) - 1; idx >= 0; idx-- {
+		if info, err := os.Lstat(t.createdDirs[idx]); err == nil && info.IsDir() {
+			os.Remove(t.createdDirs[idx])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ensureStage creates the staging directory on first use.
+func (t *Transaction) ensureStage() error {
+	if t.stage != "" {
+		return nil
+	}
+	if err := t.mkdirAll(t.dir); err != nil {
+		return err
+	}
+	stage, err := os.MkdirTemp(t.dir, stageDirPattern)
+	if err != nil {
+		return fmt.Errorf("creating staging directory: %w", err)
+	}
+	t.stage = stage
+	return nil
+}
+
+// abort rolls back after a failed commit and returns the original error.
+func (t *Transaction) abort(err error) error {
+	if rbErr := t.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+	}
+	return err
+}
+
+// cleanup removes the staging directory.
+func (t *Transaction) cleanup() error {
+	if t.stage == "" {
+		return nil
+	}
+	if err := os.RemoveAll(t.stage); err != nil {
+		return fmt.Errorf("removing staging directory: %w", err)
+	}
+	t.stage = ""
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents, remembering which ones it
+// created so Rollback can remove them again.
+func (t *Transaction) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for idx := len(missing) - 1; idx >= 0; idx-- {
+		t.createdDirs = append(t.createdDirs, missing[idx])
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	return nil
+}
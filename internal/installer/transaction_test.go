@@ -0,0 +1,123 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// listTree returns every path under dir, relative and slash-separated.
+func listTree(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	return paths
+}
+
+func TestTransaction_StagesUntilCommit(t *testing.T) {
+	testFS := fstest.MapFS{
+		"skills/one/SKILL.md":            &fstest.MapFile{Data: []byte("---\nname: one\n---\n")},
+		"skills/one/references/guide.md": &fstest.MapFile{Data: []byte("# Guide")},
+	}
+	root := filepath.Join(t.TempDir(), ".claude")
+	tx := NewTransaction(root)
+	inst := New(testFS, Options{})
+	inst.SetTransaction(tx)
+
+	results, err := inst.InstallSkills(filepath.Join(root, "skills"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !strings.HasPrefix(results[0], "CREATED:") {
+		t.Errorf("unexpected results: %v", results)
+	}
+	if fileExists(filepath.Join(root, "skills", "one", "SKILL.md")) {
+		t.Fatal("file written before commit")
+	}
+
+	finished := false
+	if err := tx.Commit(func() error { finished = true; return nil }); err != nil {
+		t.Fatalf("Commit() error: %v", err)
+	}
+	if !finished {
+		t.Error("finish step not run")
+	}
+	if got := readString(t, filepath.Join(root, "skills", "one", "references", "guide.md")); got != "# Guide" {
+		t.Errorf("guide.md = %q", got)
+	}
+	for _, p := range listTree(t, root) {
+		if strings.HasPrefix(p, ".skill-installer-stage-") {
+			t.Errorf("staging directory left behind: %s", p)
+		}
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Rollback() after Commit should be a no-op, got %v", err)
+	}
+	if !fileExists(filepath.Join(root, "skills", "one", "SKILL.md")) {
+		t.Error("Rollback() after Commit removed files")
+	}
+}
+
+func TestTransaction_RollbackBeforeCommit(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, ".claude")
+	tx := NewTransaction(root)
+	if err := tx.Stage(filepath.Join(root, "skills", "a.md"), []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if paths := listTree(t, dir); len(paths) != 0 {
+		t.Errorf("rollback left %v", paths)
+	}
+}
+
+func TestTransaction_FailedCommitRestoresTree(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".claude")
+	existing := filepath.Join(root, "skills", "one", "SKILL.md")
+	os.MkdirAll(filepath.Dir(existing), 0755)
+	os.WriteFile(existing, []byte("original"), 0644)
+	// A file where a directory is needed makes the last move fail.
+	os.WriteFile(filepath.Join(root, "blocker"), []byte("x"), 0644)
+	before := listTree(t, root)
+
+	tx := NewTransaction(root)
+	tx.Stage(existing, []byte("new"))
+	tx.Stage(filepath.Join(root, "skills", "two", "SKILL.md"), []byte("two"))
+	tx.Stage(filepath.Join(root, "blocker", "three.md"), []byte("three"))
+
+	if err := tx.Commit(nil); err == nil {
+		t.Fatal("expected Commit() to fail")
+	}
+	if got := readString(t, existing); got != "original" {
+		t.Errorf("existing file = %q, want original content restored", got)
+	}
+	if after := listTree(t, root); strings.Join(after, ",") != strings.Join(before, ",") {
+		t.Errorf("tree changed:\nbefore %v\nafter  %v", before, after)
+	}
+}
+
+func TestTransaction_FailedFinishRollsBack(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".claude")
+	tx := NewTransaction(root)
+	tx.Stage(filepath.Join(root, "agents", "debugger.md"), []byte("# Debugger"))
+
+	wantErr := errors.New("saving manifest")
+	if err := tx.Commit(func() error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Fatalf("Commit() error = %v, want %v", err, wantErr)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", root, listTree(t, root))
+	}
+}
@@ -0,0 +1,140 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Uninstall removes the files recorded in m. When skills is non-empty only
+// those skills are removed; otherwise every skill, agent and command goes.
+// Files modified since install are kept unless Force is set. Directories left
+// empty are pruned up to (but not including) the manifest root, and the
+// manifest is rewritten — or deleted once nothing is left in it.
+func (i *Installer) Uninstall(m *Manifest, skills []string) ([]string, error) {
+	var results []string
+	var kept []ManifestFile
+
+	for _, f := range m.Files {
+		if len(skills) > 0 && (f.Kind != KindSkill || !contains(skills, f.Name)) {
+			kept = append(kept, f)
+			continue
+		}
+
+		target := m.AbsPath(f)
+		current, err := HashFile(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			results = append(results, fmt.Sprintf("MISSING: %s (already removed)", target))
+			if !i.options.DryRun {
+				pruneEmptyDirs(filepath.Dir(target), m.Root())
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", target, err)
+		}
+
+		if current != f.SHA256 && !i.options.Force {
+			results = append(results, fmt.Sprintf("SKIP: %s (modified since install, use --force to remove)", target))
+			kept = append(kept, f)
+			continue
+		}
+
+		if i.options.DryRun {
+			results = append(results, fmt.Sprintf("WOULD REMOVE: %s", target))
+			continue
+		}
+
+		if err := os.Remove(target); err != nil {
+			return nil, fmt.Errorf("removing %s: %w", target, err)
+		}
+		pruneEmptyDirs(filepath.Dir(target), m.Root())
+		results = append(results, fmt.Sprintf("REMOVED: %s", target))
+	}
+
+	var keptLinks []ManifestLink
+	for _, l := range m.Links {
+		if len(skills) > 0 && !contains(skills, l.Name) {
+			keptLinks = append(keptLinks, l)
+			continue
+		}
+		result, keep, err := i.unlink(m, l)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		if keep {
+			keptLinks = append(keptLinks, l)
+		}
+	}
+
+	if i.options.DryRun {
+		return results, nil
+	}
+
+	m.Files = kept
+	m.Links = keptLinks
+	if m.Empty() {
+		if err := os.Remove(m.Path()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return results, fmt.Errorf("removing %s: %w", m.Path(), err)
+		}
+		return results, m.removeState()
+	}
+	return results, m.Save()
+}
+
+// unlink removes a linked skill. Links re-pointed by hand are kept unless
+// Force is set, and a link replaced by a real file or directory is left
+// alone and forgotten; the store itself is never touched.
+func (i *Installer) unlink(m *Manifest, l ManifestLink) (string, bool, error) {
+	linkPath := m.LinkPath(l)
+	info, err := os.Lstat(linkPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("MISSING: %s (already removed)", linkPath), false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("reading %s: %w", linkPath, err)
+	}
+
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return fmt.Sprintf("KEPT: %s (no longer a link, left in place)", linkPath), false, nil
+	}
+	if current, _ := os.Readlink(linkPath); current != l.Target && !i.options.Force {
+		return fmt.Sprintf("SKIP: %s (modified since install, use --force to remove)", linkPath), true, nil
+	}
+	if i.options.DryRun {
+		return fmt.Sprintf("WOULD REMOVE: %s", linkPath), false, nil
+	}
+
+	if err := os.Remove(linkPath); err != nil {
+		return "", false, fmt.Errorf("removing %s: %w", linkPath, err)
+	}
+	pruneEmptyDirs(filepath.Dir(linkPath), m.Root())
+	return fmt.Sprintf("REMOVED: %s", linkPath), false, nil
+}
+
+// pruneEmptyDirs removes dir and its parents while they are empty, stopping at
+// root.
+func pruneEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+	for {
+		dir = filepath.Clean(dir)
+		if dir == root || dir == "." || dir == string(filepath.Separator) {
+			return
+		}
+		if rel, err := filepath.Rel(root, dir); err != nil || strings.HasPrefix(rel, "..") {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,177 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func installForUninstall(t *testing.T) (string, *Manifest) {
+	t.Helper()
+	testFS := fstest.MapFS{
+		"skills/one/SKILL.md": &fstest.MapFile{
+			Data: []byte("---\nname: one\ndescription: One\n---\n# One"),
+		},
+		"skills/one/references/guide.md": &fstest.MapFile{
+			Data: []byte("# Guide"),
+		},
+		"skills/two/SKILL.md": &fstest.MapFile{
+			Data: []byte("---\nname: two\ndescription: Two\n---\n# Two"),
+		},
+		"agents/debugger.md": &fstest.MapFile{
+			Data: []byte("# Debugger"),
+		},
+	}
+
+	root := filepath.Join(t.TempDir(), ".claude")
+	m := NewManifest(root)
+	inst := New(testFS, Options{})
+	inst.SetManifest(m)
+	if _, err := inst.InstallSkills(filepath.Join(root, "skills"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.InstallAgents(filepath.Join(root, "agents"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return root, m
+}
+
+func TestUninstall_RemovesEverythingAndPrunes(t *testing.T) {
+	root, m := installForUninstall(t)
+
+	// A user-authored skill next to ours must survive
+	userSkill := filepath.Join(root, "skills", "mine", "SKILL.md")
+	if err := os.MkdirAll(filepath.Dir(userSkill), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userSkill, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inst := New(nil, Options{})
+	results, err := inst.Uninstall(m, nil)
+	if err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d: %v", len(results), results)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "skills", "one")); !os.IsNotExist(err) {
+		t.Error("empty skill directory was not pruned")
+	}
+	if _, err := os.Stat(filepath.Join(root, "agents")); !os.IsNotExist(err) {
+		t.Error("empty agents directory was not pruned")
+	}
+	if !fileExists(userSkill) {
+		t.Error("user-authored skill was removed")
+	}
+	if fileExists(m.Path()) {
+		t.Error("manifest should be deleted once empty")
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Error("manifest root should never be pruned")
+	}
+}
+
+func TestUninstall_SingleSkill(t *testing.T) {
+	root, m := installForUninstall(t)
+
+	inst := New(nil, Options{})
+	if _, err := inst.Uninstall(m, []string{"one"}); err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+
+	if fileExists(filepath.Join(root, "skills", "one", "SKILL.md")) {
+		t.Error("skill 'one' was not removed")
+	}
+	if !fileExists(filepath.Join(root, "skills", "two", "SKILL.md")) {
+		t.Error("skill 'two' should be kept")
+	}
+	if !fileExists(filepath.Join(root, "agents", "debugger.md")) {
+		t.Error("agents should be kept when uninstalling a single skill")
+	}
+
+	reloaded, err := LoadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Skills) != 1 || reloaded.Skills[0] != "two" {
+		t.Errorf("manifest skills = %v, want [two]", reloaded.Skills)
+	}
+}
+
+func TestUninstall_ModifiedFileNeedsForce(t *testing.T) {
+	root, m := installForUninstall(t)
+	edited := filepath.Join(root, "skills", "two", "SKILL.md")
+	if err := os.WriteFile(edited, []byte("local edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New(nil, Options{}).Uninstall(m, []string{"two"})
+	if err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "SKIP:") {
+		t.Fatalf("expected a single SKIP result, got %v", results)
+	}
+	if !fileExists(edited) {
+		t.Fatal("modified file removed without --force")
+	}
+	if _, ok := m.Lookup(edited); !ok {
+		t.Error("kept file should stay in the manifest")
+	}
+
+	if _, err := New(nil, Options{Force: true}).Uninstall(m, []string{"two"}); err != nil {
+		t.Fatalf("Uninstall() with force error: %v", err)
+	}
+	if fileExists(edited) {
+		t.Error("modified file not removed with --force")
+	}
+}
+
+func TestUninstall_DryRun(t *testing.T) {
+	root, m := installForUninstall(t)
+
+	results, err := New(nil, Options{DryRun: true}).Uninstall(m, nil)
+	if err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	for _, r := range results {
+		if !strings.HasPrefix(r, "WOULD REMOVE:") {
+			t.Errorf("unexpected dry-run result %q", r)
+		}
+	}
+	if !fileExists(filepath.Join(root, "skills", "one", "SKILL.md")) {
+		t.Error("dry run removed a file")
+	}
+	if !fileExists(m.Path()) {
+		t.Error("dry run removed the manifest")
+	}
+}
+
+func TestUninstall_MissingFile(t *testing.T) {
+	root, m := installForUninstall(t)
+	if err := os.Remove(filepath.Join(root, "agents", "debugger.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New(nil, Options{}).Uninstall(m, nil)
+	if err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	found := false
+	for _, r := range results {
+		if strings.HasPrefix(r, "MISSING:") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a MISSING result, got %v", results)
+	}
+}
@@ -0,0 +1,321 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UpdateTargets tells Update where each kind of content is installed.
+// Empty directories are skipped.
+type UpdateTargets struct {
+	SkillsDir   string
+	AgentsDir   string
+	CommandsDir string
+	AgentName   AgentNameFunc
+}
+
+// ConflictChoice is how a conflicting file should be resolved.
+type ConflictChoice int
+
+const (
+	// ConflictMarkers writes the merge result with conflict markers.
+	ConflictMarkers ConflictChoice = iota
+	// ConflictKeepLocal leaves the local file untouched.
+	ConflictKeepLocal
+	// ConflictTakeUpstream replaces the local file with the new version.
+	ConflictTakeUpstream
+)
+
+// ConflictFunc decides how to resolve a file changed both locally and
+// upstream. Pass nil to always write conflict markers.
+type ConflictFunc func(filePath string, local, upstream []byte) (ConflictChoice, error)
+
+// upstreamFile is a file the current content would install.
+type upstreamFile struct {
+	kind    string
+	name    string
+	path    string
+	content []byte
+}
+
+// Update brings the installed files recorded in m up to date with the
+// installer's content using a three-way merge: untouched files are replaced,
+// local-only edits are kept, and files changed on both sides are merged, with
+// resolve deciding what happens to real conflicts. Files no longer shipped
+// are removed unless they were modified locally.
+func (i *Installer) Update(m *Manifest, dests UpdateTargets, resolve ConflictFunc) ([]string, error) {
+	files, err := i.upstreamFiles(m, dests)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []string
+	shipped := make(map[string]bool)
+
+	for _, uf := range files {
+		shipped[uf.path] = true
+		result, err := i.updateFile(m, uf, resolve)
+		if err != nil {
+			return nil, err
+		}
+		if result != "" {
+			results = append(results, result)
+		}
+	}
+
+	// Prune files that are no longer shipped.
+	var stale []ManifestFile
+	for _, f := range m.Files {
+		if !shipped[m.AbsPath(f)] && i.covers(m, f, dests) {
+			stale = append(stale, f)
+		}
+	}
+	for _, f := range stale {
+		target := m.AbsPath(f)
+		current, err := HashFile(target)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// Already gone
+		case err != nil:
+			return nil, fmt.Errorf("reading %s: %w", target, err)
+		case current != f.SHA256:
+			results = append(results, fmt.Sprintf("KEPT: %s (removed upstream, modified locally)", target))
+		case i.options.DryRun:
+			results = append(results, fmt.Sprintf("WOULD REMOVE: %s (removed upstream)", target))
+			continue
+		default:
+			if err := os.Remove(target); err != nil {
+				return nil, fmt.Errorf("removing %s: %w", target, err)
+			}
+			pruneEmptyDirs(filepath.Dir(target), m.Root())
+			results = append(results, fmt.Sprintf("REMOVED: %s (removed upstream)", target))
+		}
+		if !i.options.DryRun {
+			m.Forget(f.Path)
+		}
+	}
+
+	if i.options.DryRun {
+		return results, nil
+	}
+	return results, m.Save()
+}
+
+// updateFile applies the three-way decision for a single file.
+func (i *Installer) updateFile(m *Manifest, uf upstreamFile, resolve ConflictFunc) (string, error) {
+	newHash := HashBytes(uf.content)
+	entry, owned := m.Lookup(uf.path)
+
+	local, err := os.ReadFile(uf.path)
+	localMissing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !localMissing {
+		return "", fmt.Errorf("reading %s: %w", uf.path, err)
+	}
+	localHash := HashBytes(local)
+
+	switch {
+	case localMissing && owned:
+		return fmt.Sprintf("SKIP: %s (deleted locally)", uf.path), nil
+
+	case localMissing:
+		return i.replace(m, uf, "CREATED")
+
+	case localHash == newHash:
+		// Already up to date; make sure the manifest agrees.
+		if !i.options.DryRun && (!owned || entry.SHA256 != newHash) {
+			m.Record(uf.kind, uf.name, uf.path, uf.content)
+		}
+		return "", nil
+
+	case !owned:
+		return fmt.Sprintf("SKIP: %s (not installed by skill-installer)", uf.path), nil
+
+	case localHash == entry.SHA256:
+		return i.replace(m, uf, "UPDATED")
+
+	case newHash == entry.SHA256:
+		return fmt.Sprintf("KEPT: %s (modified locally)", uf.path), nil
+	}
+
+	// Changed on both sides.
+	base, err := m.Object(entry.SHA256)
+	if err != nil {
+		base = nil // Original content unavailable; everything conflicts
+	}
+
+	if !isBinary(local) && !isBinary(uf.content) {
+		merged, conflicts := Merge3(base, local, uf.content)
+		if conflicts == 0 {
+			return i.writeMerged(m, uf, merged, "MERGED")
+		}
+	}
+
+	choice := ConflictMarkers
+	if resolve != nil && !i.options.DryRun {
+		choice, err = resolve(uf.path, local, uf.content)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch choice {
+	case ConflictTakeUpstream:
+		return i.replace(m, uf, "UPDATED")
+	case ConflictKeepLocal:
+		if !i.options.DryRun {
+			m.Record(uf.kind, uf.name, uf.path, uf.content)
+		}
+		return fmt.Sprintf("KEPT: %s (conflict resolved in favor of local)", uf.path), nil
+	}
+
+	if isBinary(local) || isBinary(uf.content) {
+		return fmt.Sprintf("CONFLICT: %s (binary file changed locally and upstream, kept local)", uf.path), nil
+	}
+	merged, conflicts := Merge3(base, local, uf.content)
+	result, err := i.writeMerged(m, uf, merged, "CONFLICT")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%d conflict(s), resolve the markers by hand)", result, conflicts), nil
+}
+
+// dryRunVerbs maps result verbs to their dry-run form.
+var dryRunVerbs = map[string]string{
+	"CREATED":  "CREATE",
+	"UPDATED":  "UPDATE",
+	"MERGED":   "MERGE",
+	"CONFLICT": "CONFLICT",
+}
+
+// replace writes the upstream content over the local file.
+func (i *Installer) replace(m *Manifest, uf upstreamFile, verb string) (string, error) {
+	return i.writeMerged(m, uf, uf.content, verb)
+}
+
+// writeMerged writes content to the file and records the upstream version as
+// the new base.
+func (i *Installer) writeMerged(m *Manifest, uf upstreamFile, content []byte, verb string) (string, error) {
+	if i.options.DryRun {
+		return fmt.Sprintf("WOULD %s: %s", dryRunVerbs[verb], uf.path), nil
+	}
+
+	if err := i.saveBackup(uf.path, content); err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(uf.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	if err := os.WriteFile(uf.path, content, 0644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", uf.path, err)
+	}
+	m.Record(uf.kind, uf.name, uf.path, uf.content)
+	return fmt.Sprintf("%s: %s", verb, uf.path), nil
+}
+
+// covers reports whether this update manages f: its kind is covered and it
+// is not a skill added from a registry.
+func (i *Installer) covers(m *Manifest, f ManifestFile, dests UpdateTargets) bool {
+	if _, added := m.Package(f.Name); added && f.Kind == KindSkill {
+		return false
+	}
+	return i.coversKind(f.Kind, dests)
+}
+
+// coversKind reports whether this update manages files of the given kind.
+func (i *Installer) coversKind(kind string, dests UpdateTargets) bool {
+	switch kind {
+	case KindSkill:
+		return dests.SkillsDir != ""
+	case KindAgent:
+		return dests.AgentsDir != ""
+	case KindCommand:
+		return dests.CommandsDir != ""
+	}
+	return false
+}
+
+// upstreamFiles lists what the installer's content would install for the
+// skills, agents and commands already recorded in m.
+func (i *Installer) upstreamFiles(m *Manifest, dests UpdateTargets) ([]upstreamFile, error) {
+	var files []upstreamFile
+
+	installedSkills := m.names(KindSkill)
+	if dests.SkillsDir != "" && len(installedSkills) > 0 {
+		skills, err := i.discoverSkills()
+		if err != nil {
+			return nil, err
+		}
+		for _, skill := range skills {
+			name := path.Base(skill.DirPath)
+			if _, added := m.Package(name); added || !contains(installedSkills, name) {
+				continue
+			}
+			paths, err := i.listDirFiles(skill.DirPath)
+			if err != nil {
+				return nil, fmt.Errorf("listing files in %s: %w", skill.DirPath, err)
+			}
+			for _, p := range paths {
+				data, err := fs.ReadFile(i.fsys, p)
+				if err != nil {
+					return nil, fmt.Errorf("reading %s: %w", p, err)
+				}
+				files = append(files, upstreamFile{
+					kind:    KindSkill,
+					name:    name,
+					path:    filepath.Join(dests.SkillsDir, strings.TrimPrefix(p, "skills/")),
+					content: data,
+				})
+			}
+		}
+	}
+
+	if dests.AgentsDir != "" && len(m.names(KindAgent)) > 0 {
+		entries, _ := fs.ReadDir(i.fsys, "agents")
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+				continue
+			}
+			data, err := fs.ReadFile(i.fsys, path.Join("agents", entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("reading agent %s: %w", entry.Name(), err)
+			}
+			destName := entry.Name()
+			if dests.AgentName != nil {
+				destName = dests.AgentName(destName)
+			}
+			files = append(files, upstreamFile{
+				kind:    KindAgent,
+				name:    destName,
+				path:    filepath.Join(dests.AgentsDir, destName),
+				content: data,
+			})
+		}
+	}
+
+	if dests.CommandsDir != "" && len(m.names(KindCommand)) > 0 {
+		paths, _ := i.listDirFiles("commands")
+		for _, p := range paths {
+			data, err := fs.ReadFile(i.fsys, p)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", p, err)
+			}
+			relPath := strings.TrimPrefix(p, "commands/")
+			files = append(files, upstreamFile{
+				kind:    KindCommand,
+				name:    strings.SplitN(relPath, "/", 2)[0],
+				path:    filepath.Join(dests.CommandsDir, relPath),
+				content: data,
+			})
+		}
+	}
+
+	sort.Slice(files, func(a, b int) bool { return files[a].path < files[b].path })
+	return files, nil
+}
@@ -0,0 +1,206 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func setupUpdate(t *testing.T, old fstest.MapFS) (string, *Manifest, UpdateTargets) {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), ".claude")
+	dests := UpdateTargets{
+		SkillsDir:   filepath.Join(root, "skills"),
+		AgentsDir:   filepath.Join(root, "agents"),
+		CommandsDir: filepath.Join(root, "commands"),
+	}
+
+	m := NewManifest(root)
+	inst := New(old, Options{})
+	inst.SetManifest(m)
+	if _, err := inst.InstallSkills(dests.SkillsDir, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.InstallAgents(dests.AgentsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inst.InstallCommands(dests.CommandsDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, loaded, dests
+}
+
+func readString(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func resultFor(results []string, suffix string) string {
+	for _, r := range results {
+		if strings.Contains(r, suffix) {
+			return r
+		}
+	}
+	return ""
+}
+
+func TestUpdate_ThreeWay(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/s/SKILL.md":     {Data: []byte("---\nname: s\n---\nline1\nline2\nline3\nline4\nline5\n")},
+		"skills/s/untouched.md": {Data: []byte("old\n")},
+		"skills/s/local.md":     {Data: []byte("same\n")},
+		"skills/s/gone.md":      {Data: []byte("gone\n")},
+		"skills/s/gone-edit.md": {Data: []byte("gone\n")},
+		"skills/s/clash.md":     {Data: []byte("base\n")},
+		"agents/debugger.md":    {Data: []byte("agent v1\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+	skillDir := filepath.Join(root, "skills", "s")
+
+	// Local edits
+	os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: s\n---\nLINE1\nline2\nline3\nline4\nline5\n"), 0644)
+	os.WriteFile(filepath.Join(skillDir, "local.md"), []byte("mine\n"), 0644)
+	os.WriteFile(filepath.Join(skillDir, "gone-edit.md"), []byte("edited\n"), 0644)
+	os.WriteFile(filepath.Join(skillDir, "clash.md"), []byte("mine\n"), 0644)
+
+	updated := fstest.MapFS{
+		"skills/s/SKILL.md":     {Data: []byte("---\nname: s\n---\nline1\nline2\nline3\nline4\nLINE5\n")},
+		"skills/s/untouched.md": {Data: []byte("new\n")},
+		"skills/s/local.md":     {Data: []byte("same\n")},
+		"skills/s/clash.md":     {Data: []byte("theirs\n")},
+		"skills/s/added.md":     {Data: []byte("added\n")},
+		"agents/debugger.md":    {Data: []byte("agent v2\n")},
+	}
+
+	results, err := New(updated, Options{}).Update(m, dests, nil)
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	checks := []struct {
+		file, prefix, content string
+	}{
+		{"SKILL.md", "MERGED:", "---\nname: s\n---\nLINE1\nline2\nline3\nline4\nLINE5\n"},
+		{"untouched.md", "UPDATED:", "new\n"},
+		{"local.md", "KEPT:", "mine\n"},
+		{"added.md", "CREATED:", "added\n"},
+		{"gone-edit.md", "KEPT:", "edited\n"},
+		{"clash.md", "CONFLICT:", "<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> upstream\n"},
+	}
+	for _, c := range checks {
+		r := resultFor(results, c.file)
+		if !strings.HasPrefix(r, c.prefix) {
+			t.Errorf("%s: result %q, want prefix %q", c.file, r, c.prefix)
+		}
+		if got := readString(t, filepath.Join(skillDir, c.file)); got != c.content {
+			t.Errorf("%s: content %q, want %q", c.file, got, c.content)
+		}
+	}
+
+	if fileExists(filepath.Join(skillDir, "gone.md")) {
+		t.Error("file removed upstream was not pruned")
+	}
+	if !strings.HasPrefix(resultFor(results, "gone.md"), "REMOVED:") {
+		t.Errorf("gone.md result = %q", resultFor(results, "gone.md"))
+	}
+	if got := readString(t, filepath.Join(root, "agents", "debugger.md")); got != "agent v2\n" {
+		t.Errorf("agent not updated: %q", got)
+	}
+
+	// The manifest now tracks the new upstream content as the base.
+	reloaded, err := LoadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := reloaded.Lookup(filepath.Join(skillDir, "clash.md"))
+	if !ok || f.SHA256 != HashBytes([]byte("theirs\n")) {
+		t.Error("conflicting file should be based on the new upstream version")
+	}
+	if _, ok := reloaded.Lookup(filepath.Join(skillDir, "gone-edit.md")); ok {
+		t.Error("file removed upstream should no longer be owned")
+	}
+	if _, ok := reloaded.Lookup(filepath.Join(skillDir, "added.md")); !ok {
+		t.Error("new upstream file should be recorded")
+	}
+}
+
+func TestUpdate_ConflictResolver(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/s/SKILL.md": {Data: []byte("---\nname: s\n---\nbase\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+	skillMD := filepath.Join(root, "skills", "s", "SKILL.md")
+	os.WriteFile(skillMD, []byte("---\nname: s\n---\nmine\n"), 0644)
+
+	updated := fstest.MapFS{
+		"skills/s/SKILL.md": {Data: []byte("---\nname: s\n---\ntheirs\n")},
+	}
+
+	asked := 0
+	resolve := func(string, []byte, []byte) (ConflictChoice, error) {
+		asked++
+		return ConflictTakeUpstream, nil
+	}
+	if _, err := New(updated, Options{}).Update(m, dests, resolve); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("resolver called %d times, want 1", asked)
+	}
+	if got := readString(t, skillMD); got != "---\nname: s\n---\ntheirs\n" {
+		t.Errorf("content = %q, want upstream", got)
+	}
+}
+
+func TestUpdate_DryRun(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/s/SKILL.md": {Data: []byte("---\nname: s\n---\nv1\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+	updated := fstest.MapFS{
+		"skills/s/SKILL.md": {Data: []byte("---\nname: s\n---\nv2\n")},
+	}
+
+	results, err := New(updated, Options{DryRun: true}).Update(m, dests, nil)
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0], "WOULD UPDATE:") {
+		t.Errorf("results = %v", results)
+	}
+	if got := readString(t, filepath.Join(root, "skills", "s", "SKILL.md")); got != "---\nname: s\n---\nv1\n" {
+		t.Error("dry run modified a file")
+	}
+}
+
+func TestUpdate_IgnoresUninstalledSkills(t *testing.T) {
+	old := fstest.MapFS{
+		"skills/s/SKILL.md": {Data: []byte("---\nname: s\n---\n")},
+	}
+	root, m, dests := setupUpdate(t, old)
+	updated := fstest.MapFS{
+		"skills/s/SKILL.md":     {Data: []byte("---\nname: s\n---\n")},
+		"skills/other/SKILL.md": {Data: []byte("---\nname: other\n---\n")},
+	}
+
+	if _, err := New(updated, Options{}).Update(m, dests, nil); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "skills", "other")); !os.IsNotExist(err) {
+		t.Error("update installed a skill that was never installed")
+	}
+}
@@ -0,0 +1,168 @@
+package installer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureExtensions are the detached signature files looked for next to an
+// archive, in order: minisign's, then a bare base64 ed25519 signature.
+var SignatureExtensions = []string{".minisig", ".sig"}
+
+// PublicKey is a key trusted to sign archives.
+type PublicKey struct {
+	ID  string // Hex key ID: minisign's, or a digest of a raw ed25519 key
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a trusted key: a minisign public key (the base64
+// line of a .pub file, starting "RW"), or a raw ed25519 key written as
+// "ed25519:<base64>".
+func ParsePublicKey(s string) (PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if raw, ok := strings.CutPrefix(s, "ed25519:"); ok {
+		data, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil || len(data) != ed25519.PublicKeySize {
+			return PublicKey{}, fmt.Errorf("invalid ed25519 key %q", s)
+		}
+		sum := sha256.Sum256(data)
+		return PublicKey{ID: hex.EncodeToString(sum[:8]), key: data}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+		return PublicKey{}, fmt.Errorf("invalid minisign public key %q", s)
+	}
+	return PublicKey{ID: hex.EncodeToString(data[2:10]), key: data[10:]}, nil
+}
+
+// Verifier checks a downloaded archive before it is extracted.
+type Verifier struct {
+	SHA256 string      // Expected SHA-256 of the archive, hex; empty to skip
+	Keys   []PublicKey // Trusted signing keys; when set, a valid signature is required
+}
+
+// RequiresSignature reports whether archives must carry a trusted signature.
+func (v *Verifier) RequiresSignature() bool {
+	return v != nil && len(v.Keys) > 0
+}
+
+// checkDigest compares an archive's digest with the pinned one, if any.
+func (v *Verifier) checkDigest(digest string) error {
+	if v == nil || v.SHA256 == "" || strings.EqualFold(v.SHA256, digest) {
+		return nil
+	}
+	return fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", strings.ToLower(v.SHA256), digest)
+}
+
+// trusts reports whether content signed by keyID (empty if unsigned) is
+// acceptable.
+func (v *Verifier) trusts(keyID string) bool {
+	if !v.RequiresSignature() {
+		return true
+	}
+	for _, k := range v.Keys {
+		if k.ID == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyFile checks the archive at archivePath, whose SHA-256 is digest,
+// against the pin and, when keys are trusted, the detached signature sig
+// (nil if none was found). It returns the ID of the key that signed it.
+func (v *Verifier) verifyFile(archivePath, digest string, sig []byte) (string, error) {
+	if err := v.checkDigest(digest); err != nil {
+		return "", err
+	}
+	if !v.RequiresSignature() {
+		return "", nil
+	}
+	if sig == nil {
+		return "", fmt.Errorf("no signature found (looked for %s), and trusted keys are configured", strings.Join(SignatureExtensions, ", "))
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		return "", err
+	}
+	return VerifySignature(v.Keys, data, sig)
+}
+
+// VerifySignature checks a detached signature over data against the trusted
+// keys and returns the ID of the key that made it. Both minisign signatures
+// (legacy and pre-hashed, including the trusted comment) and bare ed25519
+// signatures (64 bytes, raw or base64) are accepted.
+func VerifySignature(keys []PublicKey, data, sig []byte) (string, error) {
+	if bytes.HasPrefix(sig, []byte("untrusted comment:")) {
+		return verifyMinisign(keys, data, sig)
+	}
+
+	raw := sig
+	if len(raw) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return "", errors.New("signature verification failed: unrecognized signature format")
+		}
+		raw = decoded
+	}
+	for _, k := range keys {
+		if ed25519.Verify(k.key, data, raw) {
+			return k.ID, nil
+		}
+	}
+	return "", errors.New("signature verification failed: not signed by a trusted key")
+}
+
+// verifyMinisign checks a minisign signature file: an untrusted comment,
+// the signature, a trusted comment, and a global signature binding the
+// trusted comment to the signature.
+func verifyMinisign(keys []PublicKey, data, sig []byte) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.New("signature verification failed: malformed minisign signature")
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(blob) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("signature verification failed: malformed minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("signature verification failed: malformed minisign signature")
+	}
+
+	alg, keyID, signature := string(blob[:2]), hex.EncodeToString(blob[2:10]), blob[10:]
+	message := data
+	switch alg {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	default:
+		return "", fmt.Errorf("signature verification failed: unsupported minisign algorithm %q", alg)
+	}
+
+	for _, k := range keys {
+		if k.ID != keyID {
+			continue
+		}
+		if !ed25519.Verify(k.key, message, signature) {
+			return "", errors.New("signature verification failed: signature does not match the archive")
+		}
+		comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+		if !ed25519.Verify(k.key, append(append([]byte{}, signature...), comment...), global) {
+			return "", errors.New("signature verification failed: trusted comment was tampered with")
+		}
+		return k.ID, nil
+	}
+	return "", fmt.Errorf("signature verification failed: key %s is not trusted", keyID)
+}
@@ -0,0 +1,165 @@
+package installer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testSigner is an ed25519 key pair with a minisign key ID.
+type testSigner struct {
+	pub   ed25519.PublicKey
+	priv  ed25519.PrivateKey
+	keyID []byte
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{pub: pub, priv: priv, keyID: []byte{1, 2, 3, 4, 5, 6, 7, byte(pub[0])}}
+}
+
+// minisignKey returns the key as it appears in a minisign .pub file.
+func (s testSigner) minisignKey() string {
+	return base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), s.keyID...), s.pub...))
+}
+
+// rawKey returns the key in ed25519:<base64> form.
+func (s testSigner) rawKey() string {
+	return "ed25519:" + base64.StdEncoding.EncodeToString(s.pub)
+}
+
+// minisign signs data the way minisign does, prehashed ("ED") or not ("Ed").
+func (s testSigner) minisign(data []byte, prehash bool, comment string) []byte {
+	alg, message := "Ed", data
+	if prehash {
+		sum := blake2b.Sum512(data)
+		alg, message = "ED", sum[:]
+	}
+	sig := ed25519.Sign(s.priv, message)
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), comment...))
+	blob := append(append([]byte(alg), s.keyID...), sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(blob) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func mustParseKey(t *testing.T, s string) PublicKey {
+	t.Helper()
+	k, err := ParsePublicKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestVerifySignature(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	data := []byte("archive bytes")
+	trusted := []PublicKey{mustParseKey(t, other.rawKey()), mustParseKey(t, signer.minisignKey())}
+	raw := []PublicKey{mustParseKey(t, signer.rawKey())}
+
+	tampered := signer.minisign(data, true, "timestamp:1")
+	tampered = []byte(strings.Replace(string(tampered), "timestamp:1", "timestamp:2", 1))
+
+	tests := []struct {
+		name    string
+		keys    []PublicKey
+		data    []byte
+		sig     []byte
+		wantErr string
+	}{
+		{"minisign prehashed", trusted, data, signer.minisign(data, true, "c"), ""},
+		{"minisign legacy", trusted, data, signer.minisign(data, false, "c"), ""},
+		{"raw base64", raw, data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(signer.priv, data)) + "\n"), ""},
+		{"raw bytes", raw, data, ed25519.Sign(signer.priv, data), ""},
+		{"modified archive", trusted, []byte("archive bytes!"), signer.minisign(data, true, "c"), "does not match"},
+		{"untrusted minisign key", []PublicKey{mustParseKey(t, other.minisignKey())}, data, signer.minisign(data, true, "c"), "not trusted"},
+		{"untrusted raw key", []PublicKey{mustParseKey(t, other.rawKey())}, data, ed25519.Sign(signer.priv, data), "not signed by a trusted key"},
+		{"tampered trusted comment", trusted, data, tampered, "trusted comment"},
+		{"garbage", trusted, data, []byte("nonsense"), "unrecognized"},
+	}
+	for _, tt := range tests {
+		keyID, err := VerifySignature(tt.keys, tt.data, tt.sig)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr == "" && keyID == "":
+			t.Errorf("%s: no key ID returned", tt.name)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePublicKey_Invalid(t *testing.T) {
+	for _, s := range []string{"", "RWQ", "ed25519:short", base64.StdEncoding.EncodeToString(make([]byte, 42))} {
+		if _, err := ParsePublicKey(s); err == nil {
+			t.Errorf("ParsePublicKey(%q): expected error", s)
+		}
+	}
+}
+
+func TestCache_FetchURLVerified(t *testing.T) {
+	signer := newTestSigner(t)
+	archive := tarGz(t, map[string]string{"one/SKILL.md": "---\nname: one\n---\n"})
+	files := map[string][]byte{"/skills.tar.gz": archive}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+	url := srv.URL + "/skills.tar.gz"
+	keys := []PublicKey{mustParseKey(t, signer.minisignKey())}
+
+	c, _ := testCache(t)
+	wrongPin := &Verifier{SHA256: strings.Repeat("0", 64)}
+	if _, err := c.FetchURL(context.Background(), url, wrongPin); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("wrong pin: %v", err)
+	}
+	if _, err := c.FetchURL(context.Background(), url, &Verifier{Keys: keys}); err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Errorf("unsigned archive: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(c.Root(), "archives")); len(entries) != 0 {
+		t.Fatalf("rejected archive was extracted: %v", entries)
+	}
+
+	// Unverified content cached earlier is not trusted once keys are required
+	if _, err := c.FetchURL(context.Background(), url, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchURL(context.Background(), url, &Verifier{Keys: keys}); err == nil {
+		t.Error("cached unsigned content accepted")
+	}
+
+	files["/skills.tar.gz.minisig"] = signer.minisign(archive, true, "release")
+	dir, err := c.FetchURL(context.Background(), url, &Verifier{SHA256: HashBytes(archive), Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSkillDir(filepath.Join(dir, "one")) {
+		t.Errorf("verified archive not extracted: %v", listTree(t, dir))
+	}
+	entries, _ := c.Entries()
+	if len(entries) != 1 || entries[0].SignedBy != keys[0].ID {
+		t.Errorf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var lintJSON bool
+
+func runLint(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	var opts installer.LintOptions
+	if cfg != nil {
+		opts.Tags = cfg.Lint.Tags
+	}
+
+	paths := args
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	report, err := installer.Lint(paths, opts)
+	if err != nil {
+		return err
+	}
+
+	worst := sevOK
+	var errorCount, warningCount int
+	for _, issue := range report.Issues {
+		if issue.Severity == installer.LintError {
+			errorCount++
+			worst = sevError
+		} else {
+			warningCount++
+			worst = max(worst, sevWarning)
+		}
+	}
+
+	if lintJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			*installer.LintReport
+			Errors   int `json:"errors"`
+			Warnings int `json:"warnings"`
+		}{report, errorCount, warningCount}); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range report.Issues {
+			location := issue.Path
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.Path, issue.Line)
+			}
+			fmt.Printf("%s: %s: %s (%s)\n", location, issue.Severity, issue.Message, issue.Check)
+		}
+		if len(report.Issues) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Checked %d skill(s), %d agent(s), %d command(s): %d error(s), %d warning(s)\n",
+			report.Skills, report.Agents, report.Commands, errorCount, warningCount)
+	}
+
+	if worst >= sevWarning {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return &exitError{code: int(worst - sevInfo)}
+	}
+	return nil
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/futuregerald/futuregerald-claude-plugin/internal/config"
 	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
@@ -39,6 +44,7 @@ var (
 	skipCommands  bool
 	globalInstall bool
 	installMode   string
+	linkMode      bool
 )
 
 // Target represents an installation target (IDE/tool).
@@ -127,12 +133,13 @@ Configuration can be stored in .skill-installer.yaml`,
 	rootCmd.Flags().BoolVar(&skipClaude, "skip-claude-md", false, "Skip updating CLAUDE.md")
 	rootCmd.Flags().StringSliceVar(&tags, "tag", nil, "Filter skills by tags")
 	rootCmd.Flags().StringSliceVar(&languages, "lang", nil, "Filter skills by language")
-	rootCmd.Flags().StringVar(&fromSource, "from", "", "Install from source (local path, git URL, or URL)")
+	rootCmd.Flags().StringVar(&fromSource, "from", "", "Install from source (local path, git repository, or archive URL)")
 	rootCmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file path")
 	rootCmd.Flags().BoolVar(&skipAgents, "skip-agents", false, "Skip installing agents")
 	rootCmd.Flags().BoolVar(&skipCommands, "skip-commands", false, "Skip installing commands")
 	rootCmd.Flags().BoolVar(&globalInstall, "global", false, "Install to global/user-level directory")
 	rootCmd.Flags().StringVarP(&installMode, "mode", "m", "", "Installation mode: full, config-only, agents-only")
+	rootCmd.Flags().BoolVar(&linkMode, "link", false, "Symlink skills from a shared store in the user cache instead of copying them")
 
 	// Version command
 	versionCmd := &cobra.Command{
@@ -179,9 +186,278 @@ Examples:
 	initCmd.Flags().StringSliceVar(&initLangs, "lang", []string{"any"}, "Languages for the skill")
 	initCmd.Flags().StringVarP(&initDesc, "desc", "d", "", "Description of the skill")
 
-	rootCmd.AddCommand(versionCmd, listCmd, initCmd)
+	// Uninstall command
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove files installed by a previous install",
+		Long: `Remove the skills, agents, and commands recorded in the install manifest.
 
-	if err := rootCmd.Execute(); err != nil {
+Only files the installer created are touched. Files modified since install are
+kept unless --force is given, and directories left empty are removed.
+
+Examples:
+  skill-installer uninstall                          # Remove everything installed
+  skill-installer uninstall --skill brainstorming    # Remove a single skill
+  skill-installer uninstall --global --target claude`,
+		RunE: runUninstall,
+	}
+	uninstallCmd.Flags().StringSliceVar(&uninstallSkills, "skill", nil, "Only remove these skills")
+	uninstallCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	uninstallCmd.Flags().BoolVar(&globalInstall, "global", false, "Uninstall from the global/user-level directory")
+	uninstallCmd.Flags().BoolVarP(&force, "force", "f", false, "Also remove files modified since install")
+	uninstallCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be removed without making changes")
+
+	// Update command
+	updateCmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update installed skills, merging local changes",
+		Long: `Update installed skills, agents, and commands to this version.
+
+Each file is compared three ways: as originally installed, as it is now, and
+as shipped by this version. Untouched files are updated, local-only edits are
+kept, and files changed on both sides are merged. Real conflicts are written
+with conflict markers (or resolved interactively). Files no longer shipped
+are removed unless you modified them.
+
+Examples:
+  skill-installer update
+  skill-installer update --dry-run
+  skill-installer update --global --target claude --yes`,
+		RunE: runUpdate,
+	}
+	updateCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	updateCmd.Flags().BoolVar(&globalInstall, "global", false, "Update the global/user-level install")
+	updateCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would change without making changes")
+	updateCmd.Flags().BoolVarP(&nonInteract, "yes", "y", false, "Non-interactive: write conflict markers instead of prompting")
+
+	// Status command
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show drift between installed and embedded content",
+		Long: `Show whether each installed skill, agent, and command is up to date,
+modified locally, missing, outdated compared to this version, or unknown
+(not installed by skill-installer). Installs from --from or the config's
+sources are only checked against what was installed, so nothing in them is
+outdated.
+
+Examples:
+  skill-installer status
+  skill-installer status --json
+  skill-installer status --global --target claude`,
+		RunE: runStatus,
+	}
+	statusCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	statusCmd.Flags().BoolVar(&globalInstall, "global", false, "Inspect the global/user-level install")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON")
+
+	// Diff command
+	diffCmd := &cobra.Command{
+		Use:   "diff [skill]",
+		Short: "Show a unified diff between an installed skill and the embedded version",
+		Long: `Show a unified diff between the installed copy of a skill and the version
+embedded in this binary. For installs from --from or the config's sources,
+the diff is against the content originally installed.
+
+Examples:
+  skill-installer diff brainstorming
+  skill-installer diff brainstorming --global`,
+		Args: cobra.ExactArgs(1),
+		RunE: runDiff,
+	}
+	diffCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	diffCmd.Flags().BoolVar(&globalInstall, "global", false, "Use the global/user-level install")
+
+	// Doctor command
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Audit an installation for common problems",
+		Long: `Audit an installation end to end: target directories, skill frontmatter,
+.gitignore rules, leftover placeholders in the config file, git availability
+for git sources, and the home directory used for global installs.
+
+Each finding has a severity and a suggested fix. The exit code is 0 when
+everything is fine, 1 when there are warnings, and 2 when there are errors.
+
+Examples:
+  skill-installer doctor
+  skill-installer doctor --global --target claude`,
+		RunE: runDoctor,
+	}
+	doctorCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	doctorCmd.Flags().BoolVar(&globalInstall, "global", false, "Audit the global/user-level install")
+
+	// Restore command
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup]",
+		Short: "Restore files saved before they were overwritten",
+		Long: `Restore a backup snapshot. Every install, update, or config generation that
+overwrites a file first saves the old version to
+.skill-installer/backups/<timestamp>/ next to the install manifest.
+
+Without an argument the latest snapshot is restored. Files that restoring
+would overwrite are snapshotted too, so a restore can itself be undone.
+
+Examples:
+  skill-installer restore --list
+  skill-installer restore
+  skill-installer restore 20250101-120000`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runRestore,
+	}
+	restoreCmd.Flags().BoolVar(&restoreList, "list", false, "List available backups")
+	restoreCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode")
+	restoreCmd.Flags().BoolVar(&globalInstall, "global", false, "Use the global/user-level install")
+	restoreCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be restored without making changes")
+
+	// Cache command
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of remote skill sources",
+		Long: `Git repos and archives installed with --from are kept in a cache under the
+user cache directory ($SKILL_INSTALLER_CACHE_DIR/sources if set), so repeated
+installs are instant and work offline. A cached source is used as is for an
+hour; after that it is checked upstream, falling back to the cached copy when
+the source is unreachable.
+
+Examples:
+  skill-installer cache list
+  skill-installer cache prune --max-age 168h
+  skill-installer cache clear`,
+	}
+	cacheListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached sources",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheList,
+	}
+	cachePruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached sources not used recently",
+		Args:  cobra.NoArgs,
+		RunE:  runCachePrune,
+	}
+	cachePruneCmd.Flags().DurationVar(&cacheMaxAge, "max-age", 30*24*time.Hour, "Remove sources not used within this long")
+	cachePruneCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be removed without making changes")
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached source",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheClear,
+	}
+	cacheCmd.AddCommand(cacheListCmd, cachePruneCmd, cacheClearCmd)
+
+	// Search command
+	searchCmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search a skill registry",
+		Long: `Search the skills published in a registry's index.json by name, description,
+and tags. The newest version of each match is shown.
+
+The registry is an http(s) URL or a file path, given with --registry or set
+with registry: in .skill-installer.yaml.
+
+Examples:
+  skill-installer search testing --registry https://skills.example.com/index.json
+  skill-installer search --tag review
+  skill-installer search`,
+		RunE: runSearch,
+	}
+	searchCmd.Flags().StringVar(&registryLocation, "registry", "", "Registry index URL or path")
+	searchCmd.Flags().StringSliceVar(&tags, "tag", nil, "Filter by tags")
+	searchCmd.Flags().StringSliceVar(&languages, "lang", nil, "Filter by language")
+
+	// Add command
+	addCmd := &cobra.Command{
+		Use:   "add <name>[@version]",
+		Short: "Install a skill from a registry",
+		Long: `Install a single skill from a registry, at its newest version or the one
+given after @. The archive is fetched through the source cache and checked
+against the digest in the index before it is extracted.
+
+Skills added this way are recorded in the install manifest, so status,
+uninstall, and restore cover them; update leaves them alone.
+
+Examples:
+  skill-installer add code-review
+  skill-installer add code-review@1.2.0 --target cursor
+  skill-installer add code-review --registry ./registry/index.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAdd,
+	}
+	addCmd.Flags().StringVar(&registryLocation, "registry", "", "Registry index URL or path")
+	addCmd.Flags().StringVarP(&targetType, "target", "t", "", "Target: claude, copilot, opencode, cursor, vscode (default claude)")
+	addCmd.Flags().BoolVar(&globalInstall, "global", false, "Install to global/user-level directory")
+	addCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite existing files")
+	addCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without making changes")
+	addCmd.Flags().BoolVarP(&nonInteract, "yes", "y", false, "Non-interactive mode with defaults")
+
+	// Serve command
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a directory of skills as a registry",
+		Long: `Serve a directory of skills as a registry that search and add can use. The
+directory is scanned like the embedded skills: every subdirectory with a
+SKILL.md is a skill, published under its directory name at the version in
+its frontmatter (0.0.0 if none).
+
+The generated index is at /index.json and each skill is packed into
+/archives/<name>-<version>.tar.gz. Responses carry ETags, and the index lists
+each archive's SHA-256. The directory is rescanned whenever the index is
+requested.
+
+Examples:
+  skill-installer serve --dir ./skills --addr :8080
+  skill-installer search --registry http://localhost:8080/index.json`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+	serveCmd.Flags().StringVar(&serveDir, "dir", "skills", "Directory of skills to serve")
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+
+	// Lint command
+	lintCmd := &cobra.Command{
+		Use:   "lint [path]...",
+		Short: "Check skills, agents, and commands before publishing them",
+		Long: `Check the skills, agents, and commands in each path (a skill directory, or a
+source laid out as --from expects; the current directory by default):
+
+  - frontmatter parses, and skills have a name and description
+  - names are hyphen-case and match the directory or file they install as
+  - descriptions are between 20 and 1024 characters
+  - tags come from the known vocabulary (extend it with lint.tags in the config)
+  - links into references/, scripts/, assets/, and templates/ resolve
+  - files starting with #! are executable, and scripts/ files that are
+    executable start with #!
+  - no two skills, agents, or commands share a name, across all paths
+  - requires: names a skill or agent of the same source, without cycles
+
+The exit code is 0 when everything passes, 1 when there are warnings, and 2
+when there are errors.
+
+Examples:
+  skill-installer lint
+  skill-installer lint ./skills/my-skill
+  skill-installer lint --json . ../team-skills`,
+		RunE: runLint,
+	}
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "Output as JSON")
+
+	rootCmd.AddCommand(versionCmd, listCmd, initCmd, uninstallCmd, updateCmd, statusCmd, diffCmd, doctorCmd, restoreCmd, cacheCmd, searchCmd, addCmd, serveCmd, lintCmd)
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "timeout", 0, "Timeout for connecting to, and stalled downloads from, remote sources; 0 uses the config's http.timeout, or else 30s")
+
+	// Ctrl-C cancels downloads and clones in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	stop()
+	if err != nil {
+		var exitErr *exitError
+		switch {
+		case errors.As(err, &exitErr):
+			os.Exit(exitErr.code)
+		case interrupted:
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
@@ -194,6 +470,9 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	if cfg != nil && cfg.From != "" && len(cfg.Sources) > 0 {
+		return fmt.Errorf("config sets both from: and sources:; list the from: source under sources: instead")
+	}
 	applyConfig(cfg)
 
 	// Ask installation mode first (so getTarget can adapt prompts)
@@ -227,24 +506,32 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	case modeAgentsOnly:
 		return runAgentsOnly(reader, inst, target)
 	default:
-		return runFullInstall(reader, inst, target)
+		return runFullInstall(cmdContext(cmd), reader, inst, target)
 	}
 }
 
-func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Target) error {
+func runFullInstall(ctx context.Context, reader *bufio.Reader, inst *installer.Installer, target Target) error {
 	scope, err := askScope(reader, target)
 	if err != nil {
 		return err
 	}
 
-	var skillsDest, agentsDest, commandsDest string
-	if scope == "global" {
-		skillsDest = target.GlobalSkillsPath
-		agentsDest = target.GlobalAgentsPath
-	} else {
-		skillsDest = filepath.Join(".", target.SkillsPath)
-		agentsDest = filepath.Join(".", target.AgentsPath)
-		commandsDest = filepath.Join(".", target.CommandsPath)
+	skillsDest, agentsDest, commandsDest := installDests(target, scope)
+
+	manifest, err := openManifest(target, scope)
+	if err != nil {
+		return err
+	}
+	inst.SetManifest(manifest)
+
+	// Stage every write so a failure part way leaves the target untouched,
+	// and keep a copy of anything overwritten.
+	tx := installer.NewTransaction(manifest.Root())
+	defer tx.Rollback()
+	inst.SetTransaction(tx)
+	inst.SetBackup(installer.NewBackup(manifest.Root()))
+	if !force && !nonInteract {
+		inst.SetOverwriteFunc(askOverwriteChoice(reader))
 	}
 
 	updateConfig := false
@@ -259,16 +546,38 @@ func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Targ
 	fmt.Println("\nInstalling skills...")
 	var results []string
 
+	// Agents and commands come from the source when it has them
+	var source installer.SourceLayout
+	agentInst, commandInst := inst, inst
+	var agentsFS fs.FS
 	if fromSource != "" {
-		if strings.HasPrefix(fromSource, "http://") || strings.HasPrefix(fromSource, "https://") {
-			if strings.Contains(fromSource, "github.com") || strings.Contains(fromSource, "gitlab.com") {
-				results, err = inst.InstallFromGit(fromSource, skillsDest)
-			} else {
-				results, err = inst.InstallFromURL(fromSource, skillsDest)
-			}
-		} else {
-			results, err = inst.InstallFromLocal(fromSource, skillsDest)
+		source, manifest.Commit, err = resolveSource(ctx, fromSource)
+		if err != nil {
+			return err
+		}
+		if source.Agents() != "" {
+			agentsFS = os.DirFS(source.Root)
+			agentInst = inst.WithFS(agentsFS)
+		}
+		if source.Commands() != "" {
+			commandInst = inst.WithFS(os.DirFS(source.Root))
+		}
+	}
+
+	// Skills may require agents of their own source, and installed agents'
+	// requirements apply when they come from that source too. Embedded
+	// agents installed alongside another source's skills resolve nothing.
+	inst.SetAgents(agentsFS, !skipAgents && agentsDest != "")
+
+	if fromSource == "" && len(configSources) > 0 {
+		if linkMode {
+			return fmt.Errorf("--link does not support installing from several sources")
 		}
+		results, err = installMerged(ctx, inst, manifest, skillsDest)
+	} else if linkMode {
+		results, err = linkSkills(inst, source.Skills, skillsDest)
+	} else if source.Skills != "" {
+		results, err = inst.InstallFromLocal(source.Skills, skillsDest, tags, languages)
 	} else {
 		results, err = inst.InstallSkills(skillsDest, tags, languages)
 	}
@@ -279,43 +588,29 @@ func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Targ
 	for _, r := range results {
 		fmt.Println(r)
 	}
+	reportDependencies(inst.Dependencies())
 
 	// Install agents
 	if !skipAgents && agentsDest != "" {
-		overwrite, err := askOverwriteAgents(reader, agentsDest)
+		fmt.Println("\nInstalling agents...")
+		var nameFunc installer.AgentNameFunc
+		if target.Name == "GitHub Copilot" {
+			nameFunc = installer.CopilotAgentName
+		}
+
+		agentResults, err := agentInst.InstallAgents(agentsDest, nameFunc)
 		if err != nil {
 			return err
 		}
-
-		if overwrite {
-			agentInst := inst
-			if !inst.HasForce() {
-				// User confirmed overwrite — use a local installer with Force enabled
-				agentInst = installer.New(content, installer.Options{Force: true, DryRun: dryRun})
-			}
-
-			fmt.Println("\nInstalling agents...")
-			var nameFunc installer.AgentNameFunc
-			if target.Name == "GitHub Copilot" {
-				nameFunc = installer.CopilotAgentName
-			}
-
-			agentResults, err := agentInst.InstallAgents(agentsDest, nameFunc)
-			if err != nil {
-				return err
-			}
-			for _, r := range agentResults {
-				fmt.Println(r)
-			}
-		} else {
-			fmt.Println("\nSkipping agent installation.")
+		for _, r := range agentResults {
+			fmt.Println(r)
 		}
 	}
 
 	// Install commands (if target supports it and project-scoped)
 	if !skipCommands && commandsDest != "" && scope == "project" {
 		fmt.Println("\nInstalling commands...")
-		cmdResults, err := inst.InstallCommands(commandsDest)
+		cmdResults, err := commandInst.InstallCommands(commandsDest)
 		if err != nil {
 			return err
 		}
@@ -324,6 +619,10 @@ func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Targ
 		}
 	}
 
+	if err := tx.Commit(func() error { return saveManifest(manifest) }); err != nil {
+		return err
+	}
+
 	// Generate config file
 	if updateConfig {
 		err = generateConfigFile(inst, target, reader)
@@ -336,6 +635,7 @@ func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Targ
 	if scope == "project" && !dryRun {
 		ensureGitignoreAllowsProjectJSON(reader)
 	}
+	reportBackup(inst.Backup())
 
 	if dryRun {
 		fmt.Println("\n(dry run - no files were modified)")
@@ -346,7 +646,159 @@ func runFullInstall(reader *bufio.Reader, inst *installer.Installer, target Targ
 	return nil
 }
 
-func generateConfigFile(_ *installer.Installer, target Target, reader *bufio.Reader) error {
+// reportDependencies lists the skills installed only because others
+// required them.
+func reportDependencies(deps []installer.Dependency) {
+	if len(deps) == 0 {
+		return
+	}
+	fmt.Println("\nPulled in as dependencies:")
+	for _, d := range deps {
+		fmt.Printf("  %s (required by %s)\n", d.Name, d.RequiredBy)
+	}
+}
+
+// installMerged installs the skills merged from the config's sources:,
+// reporting skills that more than one source ships.
+func installMerged(ctx context.Context, inst *installer.Installer, manifest *installer.Manifest, skillsDest string) ([]string, error) {
+	skills, err := mergeSources(ctx, configSources)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Source = sourcesManifestSource
+	for _, s := range skills {
+		if report := collisionReport(s); report != "" {
+			fmt.Println(report)
+		}
+	}
+	return inst.InstallMerged(skills, skillsDest)
+}
+
+// linkSkills installs skills as symlinks into the shared store (--link),
+// storing the embedded content or the skills in srcDir first.
+func linkSkills(inst *installer.Installer, srcDir, skillsDest string) ([]string, error) {
+	storeDir, err := installer.DefaultStoreDir()
+	if err != nil {
+		return nil, err
+	}
+	store := installer.NewStore(storeDir)
+
+	if srcDir == "" {
+		return inst.LinkSkills(store, "v"+version, skillsDest, tags, languages)
+	}
+	return inst.LinkFromLocal(store, srcDir, skillsDest, tags, languages)
+}
+
+// installDests returns where skills, agents, and commands go for a target and
+// scope. Commands are only installed project-scoped.
+func installDests(target Target, scope string) (skillsDest, agentsDest, commandsDest string) {
+	if scope == "global" {
+		return target.GlobalSkillsPath, target.GlobalAgentsPath, ""
+	}
+	return projectPath(target.SkillsPath), projectPath(target.AgentsPath), projectPath(target.CommandsPath)
+}
+
+// projectPath anchors a target path at the current directory, keeping empty
+// (unsupported) paths empty.
+func projectPath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return filepath.Join(".", p)
+}
+
+// manifestRoot returns the directory holding the install manifest for a target
+// and scope: the parent of the target's skills directory (e.g. .claude).
+func manifestRoot(target Target, scope string) string {
+	if scope == "global" {
+		return filepath.Dir(target.GlobalSkillsPath)
+	}
+	return filepath.Join(".", filepath.Dir(target.SkillsPath))
+}
+
+// targetKey returns the targets map key for t (e.g. "claude").
+func targetKey(t Target) string {
+	for key, candidate := range targets {
+		if candidate.Name == t.Name {
+			return key
+		}
+	}
+	return ""
+}
+
+// openManifest loads the existing install manifest for target/scope (if any)
+// and stamps it with the details of the current run.
+func openManifest(target Target, scope string) (*installer.Manifest, error) {
+	manifest, err := installer.LoadManifest(manifestRoot(target, scope))
+	if err != nil {
+		return nil, fmt.Errorf("loading install manifest: %w", err)
+	}
+	manifest.InstallerVersion = version
+	manifest.Target = targetKey(target)
+	manifest.Scope = scope
+	manifest.Source = installer.SourceEmbedded
+	manifest.Commit = ""
+	if fromSource != "" {
+		manifest.Source = installer.RedactURL(fromSource)
+	}
+	manifest.InstalledAt = time.Now().UTC()
+	return manifest, nil
+}
+
+// findInstalledTarget locates the install manifest for --target/--global.
+// Without an explicit target, every target is checked and exactly one must
+// have a manifest in the requested scope.
+func findInstalledTarget(scope string) (Target, *installer.Manifest, error) {
+	keys := []string{"claude", "copilot", "cursor", "opencode", "vscode"}
+	if targetType != "" {
+		if _, ok := targets[targetType]; !ok {
+			return Target{}, nil, fmt.Errorf("unknown target: %s", targetType)
+		}
+		keys = []string{targetType}
+	}
+
+	var found []string
+	for _, key := range keys {
+		t := targets[key]
+		if scope == "global" && t.GlobalSkillsPath == "" {
+			continue
+		}
+		if fileExists(filepath.Join(manifestRoot(t, scope), installer.ManifestName)) {
+			found = append(found, key)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		if targetType != "" {
+			return Target{}, nil, fmt.Errorf("no %s install manifest found for %s", scope, targets[targetType].Name)
+		}
+		return Target{}, nil, fmt.Errorf("no %s install manifest found (nothing was installed by skill-installer?)", scope)
+	case 1:
+		t := targets[found[0]]
+		manifest, err := installer.LoadManifest(manifestRoot(t, scope))
+		if err != nil {
+			return Target{}, nil, fmt.Errorf("loading install manifest: %w", err)
+		}
+		return t, manifest, nil
+	default:
+		return Target{}, nil, fmt.Errorf("multiple installs found (%s), choose one with --target", strings.Join(found, ", "))
+	}
+}
+
+// saveManifest writes the install manifest unless this is a dry run or nothing
+// was installed.
+func saveManifest(manifest *installer.Manifest) error {
+	if dryRun || manifest.Empty() {
+		return nil
+	}
+	if err := manifest.Save(); err != nil {
+		return fmt.Errorf("writing install manifest: %w", err)
+	}
+	return nil
+}
+
+func generateConfigFile(inst *installer.Installer, target Target, reader *bufio.Reader) error {
 	configPath := filepath.Join(".", target.ConfigPath)
 
 	// Detect project type (safe in dry-run: only reads the filesystem)
@@ -411,6 +863,11 @@ func generateConfigFile(_ *installer.Installer, target Target, reader *bufio.Rea
 				return nil
 			}
 		}
+		if inst != nil && inst.Backup() != nil {
+			if err := inst.Backup().Save(configPath); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := os.WriteFile(configPath, configContent, 0644); err != nil {
@@ -457,10 +914,19 @@ func applyConfig(cfg *config.Config) {
 	}
 	if fromSource == "" && cfg.From != "" {
 		fromSource = cfg.From
+		sourceSHA256 = cfg.SHA256
 	}
+	configSources = cfg.Sources
+	trustedKeys = cfg.TrustedKeys
+	hostAuth = cfg.Auth
+	httpConfig = cfg.HTTP
+	archiveConfig = cfg.Archives
 	if installMode == "" && cfg.Mode != "" {
 		installMode = cfg.Mode
 	}
+	if !linkMode && cfg.Link {
+		linkMode = true
+	}
 }
 
 func getTarget(reader *bufio.Reader, mode string) (Target, error) {
@@ -651,9 +1117,11 @@ func runConfigOnly(reader *bufio.Reader, inst *installer.Installer, target Targe
 	}
 
 	fmt.Printf("\nGenerating %s...\n", target.ConfigPath)
+	inst.SetBackup(installer.NewBackup(manifestRoot(target, "project")))
 	if err := generateConfigFile(inst, target, reader); err != nil {
 		return fmt.Errorf("could not generate %s: %w", target.ConfigPath, err)
 	}
+	reportBackup(inst.Backup())
 
 	if dryRun {
 		fmt.Println("\n(dry run - no files were modified)")
@@ -683,72 +1151,89 @@ func runAgentsOnly(reader *bufio.Reader, inst *installer.Installer, target Targe
 		agentsDest = filepath.Join(".", target.AgentsPath)
 	}
 
-	overwrite, err := askOverwriteAgents(reader, agentsDest)
+	manifest, err := openManifest(target, scope)
 	if err != nil {
 		return err
 	}
+	inst.SetManifest(manifest)
 
-	agentInst := inst
-	if overwrite && !inst.HasForce() {
-		// User confirmed overwrite — use a local installer with Force enabled
-		agentInst = installer.New(content, installer.Options{Force: true, DryRun: dryRun})
+	tx := installer.NewTransaction(manifest.Root())
+	defer tx.Rollback()
+	inst.SetTransaction(tx)
+	inst.SetBackup(installer.NewBackup(manifest.Root()))
+	if !force && !nonInteract {
+		inst.SetOverwriteFunc(askOverwriteChoice(reader))
 	}
 
-	if !overwrite {
-		fmt.Println("\nSkipping agent installation.")
-	} else {
-		fmt.Println("\nInstalling agents...")
-		var nameFunc installer.AgentNameFunc
-		if target.Name == "GitHub Copilot" {
-			nameFunc = installer.CopilotAgentName
-		}
+	fmt.Println("\nInstalling agents...")
+	var nameFunc installer.AgentNameFunc
+	if target.Name == "GitHub Copilot" {
+		nameFunc = installer.CopilotAgentName
+	}
 
-		agentResults, err := agentInst.InstallAgents(agentsDest, nameFunc)
-		if err != nil {
-			return err
-		}
-		for _, r := range agentResults {
-			fmt.Println(r)
-		}
+	agentResults, err := inst.InstallAgents(agentsDest, nameFunc)
+	if err != nil {
+		return err
 	}
+	for _, r := range agentResults {
+		fmt.Println(r)
+	}
+	if err := tx.Commit(func() error { return saveManifest(manifest) }); err != nil {
+		return err
+	}
+	reportBackup(inst.Backup())
 
 	if dryRun {
 		fmt.Println("\n(dry run - no files were modified)")
-	} else if overwrite {
+	} else {
 		fmt.Println("\nDone! Agents installed successfully.")
 	}
 	return nil
 }
 
-// askOverwriteAgents checks for existing .md files in the destination and prompts
-// the user for confirmation. Returns true if agents should be installed (with force).
-func askOverwriteAgents(reader *bufio.Reader, agentsDest string) (bool, error) {
-	// Check for existing .md files
-	existing, _ := filepath.Glob(filepath.Join(agentsDest, "*.md"))
-	if len(existing) == 0 {
-		return true, nil
-	}
-
-	if force {
-		return true, nil
-	}
-
-	if nonInteract {
-		return false, nil
-	}
-
-	fmt.Printf("\nExisting agent files found in %s:\n", agentsDest)
-	for _, f := range existing {
-		fmt.Printf("  - %s\n", filepath.Base(f))
-	}
-	fmt.Print("Overwrite existing agent files? [y/N]: ")
+// askOverwriteChoice returns an OverwriteFunc that asks, file by file, what to
+// do with existing files that differ from the ones being installed, in the
+// style of git add -p. An upper-case answer applies to all remaining files.
+func askOverwriteChoice(reader *bufio.Reader) installer.OverwriteFunc {
+	var all *installer.OverwriteChoice
+	return func(filePath string, existing, incoming []byte) (installer.OverwriteChoice, error) {
+		if all != nil {
+			return *all, nil
+		}
+		fmt.Printf("\n%s already exists and differs from the new version.\n", filePath)
+		for {
+			fmt.Print("Keep, overwrite, keep both (.new), show diff? [k,o,b,d,K,O,B,?]: ")
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return installer.OverwriteKeep, err
+			}
 
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return false, err
+			input = strings.TrimSpace(input)
+			var choice installer.OverwriteChoice
+			switch strings.ToLower(input) {
+			case "k", "":
+				choice = installer.OverwriteKeep
+			case "o":
+				choice = installer.OverwriteReplace
+			case "b":
+				choice = installer.OverwriteKeepBoth
+			case "d":
+				fmt.Print(installer.UnifiedDiff("existing/"+filepath.ToSlash(filePath), "new/"+filepath.ToSlash(filePath), existing, incoming))
+				continue
+			default:
+				fmt.Println("  k - keep the existing file")
+				fmt.Println("  o - overwrite it with the new version")
+				fmt.Println("  b - keep both, writing the new version to a .new file")
+				fmt.Println("  d - show the diff")
+				fmt.Println("  K, O, B - do the same for this and all remaining files")
+				continue
+			}
+			if input == strings.ToUpper(input) && input != "" {
+				all = &choice
+			}
+			return choice, nil
+		}
 	}
-	input = strings.TrimSpace(strings.ToLower(input))
-	return input == "y" || input == "yes", nil
 }
 
 // ensureGitignoreAllowsProjectJSON checks if .gitignore blocks .claude/project.json
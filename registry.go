@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var registryLocation string // --registry: index URL or path
+
+// openRegistry loads the registry index named by --registry or the config's
+// registry: setting. The config's target, trusted keys, credentials and
+// download settings are picked up too.
+func openRegistry(ctx context.Context) (*installer.RegistryIndex, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if cfg != nil {
+		if registryLocation == "" {
+			registryLocation = cfg.Registry
+		}
+		if targetType == "" {
+			targetType = cfg.Target
+		}
+		trustedKeys = cfg.TrustedKeys
+		hostAuth = cfg.Auth
+		httpConfig = cfg.HTTP
+		archiveConfig = cfg.Archives
+	}
+	if registryLocation == "" {
+		return nil, errors.New("no registry configured: pass --registry or set registry: in .skill-installer.yaml")
+	}
+	return installer.LoadRegistry(ctx, registryLocation, newAuth(), httpOptions())
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	index, err := openRegistry(cmdContext(cmd))
+	if err != nil {
+		return err
+	}
+
+	var matches []installer.RegistryEntry
+	for _, e := range index.Search(strings.Join(args, " ")) {
+		if e.Matches(tags, languages) {
+			matches = append(matches, e)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Println("No skills match.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tDESCRIPTION\tTAGS")
+	for _, e := range matches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Name, e.Version, truncate(e.Description, 60), strings.Join(e.Tags, ", "))
+	}
+	return w.Flush()
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	index, err := openRegistry(cmdContext(cmd))
+	if err != nil {
+		return err
+	}
+	name, wantVersion := installer.ParsePackageRef(args[0])
+	entry, err := index.Find(name, wantVersion)
+	if err != nil {
+		return err
+	}
+	if entry.SHA256 == "" {
+		return fmt.Errorf("registry entry for %s@%s has no sha256", entry.Name, entry.Version)
+	}
+
+	if targetType == "" {
+		targetType = "claude"
+	}
+	target, ok := targets[targetType]
+	if !ok {
+		return fmt.Errorf("unknown target: %s", targetType)
+	}
+	scope := "project"
+	if globalInstall {
+		scope = "global"
+		if target.GlobalSkillsPath == "" {
+			return fmt.Errorf("%s does not support global installs", target.Name)
+		}
+	}
+	skillsDest, _, _ := installDests(target, scope)
+
+	verifier, err := newVerifier(entry.SHA256, trustedKeys)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Adding %s@%s...\n", entry.Name, entry.Version)
+	dir, err := fetchArchive(cmdContext(cmd), index.ArchiveURL(entry), verifier)
+	if err != nil {
+		return err
+	}
+	skillDir, err := installer.FindSkillDir(dir, entry.Name)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := installer.LoadManifest(manifestRoot(target, scope))
+	if err != nil {
+		return fmt.Errorf("loading install manifest: %w", err)
+	}
+	if !manifest.Exists() {
+		manifest.InstallerVersion = version
+		manifest.Target = targetKey(target)
+		manifest.Scope = scope
+		manifest.Source = installer.SourceEmbedded
+		manifest.InstalledAt = time.Now().UTC()
+	}
+
+	inst := installer.New(content, installer.Options{Force: force, DryRun: dryRun})
+	inst.SetManifest(manifest)
+	tx := installer.NewTransaction(manifest.Root())
+	defer tx.Rollback()
+	inst.SetTransaction(tx)
+	inst.SetBackup(installer.NewBackup(manifest.Root()))
+	if !force && !nonInteract {
+		inst.SetOverwriteFunc(askOverwriteChoice(reader))
+	}
+
+	results, err := inst.InstallSkillDir(skillDir, skillsDest, entry.Name)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	manifest.RecordPackage(installer.ManifestPackage{
+		Name:     entry.Name,
+		Version:  entry.Version,
+		Registry: installer.RedactURL(registryLocation),
+		SHA256:   strings.ToLower(entry.SHA256),
+	})
+	if err := tx.Commit(func() error { return saveManifest(manifest) }); err != nil {
+		return err
+	}
+	reportBackup(inst.Backup())
+
+	if dryRun {
+		fmt.Println("\n(dry run - no files were modified)")
+	} else {
+		fmt.Printf("\nDone! Added %s@%s.\n", entry.Name, entry.Version)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var restoreList bool
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if targetType == "" && cfg != nil {
+		targetType = cfg.Target
+	}
+
+	scope := "project"
+	if globalInstall {
+		scope = "global"
+	}
+	root, err := findBackupRoot(scope)
+	if err != nil {
+		return err
+	}
+
+	if restoreList {
+		backups, err := installer.ListBackups(root)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BACKUP\tCREATED\tFILES")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", b.ID, b.Created.Local().Format("2006-01-02 15:04:05"), len(b.Files))
+		}
+		return w.Flush()
+	}
+
+	var id string
+	if len(args) > 0 {
+		id = args[0]
+	}
+	results, err := installer.RestoreBackup(root, id, dryRun)
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println("\n(dry run - no files were modified)")
+	} else {
+		fmt.Println("\nDone! Files restored (the replaced versions were backed up; run restore again to undo).")
+	}
+	return nil
+}
+
+// findBackupRoot locates the install root holding backups for
+// --target/--global. Without an explicit target, exactly one target must have
+// backups in the requested scope.
+func findBackupRoot(scope string) (string, error) {
+	keys := []string{"claude", "copilot", "cursor", "opencode", "vscode"}
+	if targetType != "" {
+		if _, ok := targets[targetType]; !ok {
+			return "", fmt.Errorf("unknown target: %s", targetType)
+		}
+		keys = []string{targetType}
+	}
+
+	var found []string
+	for _, key := range keys {
+		t := targets[key]
+		if scope == "global" && t.GlobalSkillsPath == "" {
+			continue
+		}
+		backups, err := installer.ListBackups(manifestRoot(t, scope))
+		if err != nil {
+			return "", err
+		}
+		if len(backups) > 0 {
+			found = append(found, key)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no %s backups found", scope)
+	case 1:
+		return manifestRoot(targets[found[0]], scope), nil
+	default:
+		return "", fmt.Errorf("backups found for multiple targets (%s), choose one with --target", strings.Join(found, ", "))
+	}
+}
+
+// reportBackup tells the user where overwritten files were saved, if any.
+func reportBackup(b *installer.Backup) {
+	if b == nil || len(b.Files) == 0 {
+		return
+	}
+	fmt.Printf("\nBacked up %d overwritten file(s) to %s (undo with: skill-installer restore %s)\n", len(b.Files), b.Dir(), b.ID)
+}
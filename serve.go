@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveDir  string
+	serveAddr string
+)
+
+func runServe(cmd *cobra.Command, args []string) error {
+	registry, err := installer.NewRegistryServer(serveDir)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Serving %d skills from %s at http://%s/index.json\n", registry.Skills(), serveDir, listener.Addr())
+
+	server := &http.Server{
+		Handler:           registry,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Ctrl-C stops the server, letting requests in flight finish
+	go func() {
+		<-cmdContext(cmd).Done()
+		server.Shutdown(context.Background())
+	}()
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
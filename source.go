@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/config"
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+)
+
+// sourcesManifestSource is the manifest source of installs merged from the
+// config's sources: list.
+const sourcesManifestSource = "sources"
+
+var (
+	sourceSHA256  string          // sha256 pin from the config, for its from: source
+	trustedKeys   []string        // Keys from the config whose archive signatures are accepted
+	configSources []config.Source // sources: from the config, merged when there is no --from
+	hostAuth      []config.Auth   // Credentials for private source hosts
+)
+
+// newAuth returns the credentials for downloads and clones: the config's
+// auth: entries, then ~/.netrc.
+func newAuth() *installer.Auth {
+	var hosts []installer.HostCredentials
+	for _, a := range hostAuth {
+		hosts = append(hosts, installer.HostCredentials{
+			Host:        a.Host,
+			TokenEnv:    a.TokenEnv,
+			UsernameEnv: a.UsernameEnv,
+			PasswordEnv: a.PasswordEnv,
+		})
+	}
+	return installer.NewAuth(hosts)
+}
+
+// resolveSource locates the skills, agents, and commands of a --from source,
+// fetching git repositories and archives through the cache. A "//sub/path"
+// suffix selects a directory within the source, and "?sha256=<hex>" pins an
+// archive's digest. For git sources it also returns the commit the source
+// resolved to.
+func resolveSource(ctx context.Context, src string) (installer.SourceLayout, string, error) {
+	return resolvePinnedSource(ctx, src, sourceSHA256)
+}
+
+// resolvePinnedSource is resolveSource with pin as the archive's expected
+// digest, unless the source carries its own.
+func resolvePinnedSource(ctx context.Context, src, pin string) (installer.SourceLayout, string, error) {
+	src, sum := installer.SplitChecksum(src)
+	if sum == "" {
+		sum = pin
+	}
+	verifier, err := newVerifier(sum, trustedKeys)
+	if err != nil {
+		return installer.SourceLayout{}, "", err
+	}
+
+	src, subdir := installer.SplitSubdir(src)
+	kind, location := installer.ClassifySource(ctx, src, newAuth(), httpOptions())
+	if kind == installer.SourceLocal {
+		if sum != "" {
+			return installer.SourceLayout{}, "", fmt.Errorf("sha256 pins apply to archives, and %s is a directory", location)
+		}
+		layout, err := installer.ResolveLayout(location, subdir)
+		return layout, "", err
+	}
+
+	if kind == installer.SourceArchive {
+		dir, err := fetchArchive(ctx, location, verifier)
+		if err != nil {
+			return installer.SourceLayout{}, "", err
+		}
+		layout, err := installer.ResolveLayout(dir, subdir)
+		return layout, "", err
+	}
+
+	repoURL, ref := installer.ParseGitSource(location)
+	if sum != "" {
+		return installer.SourceLayout{}, "", errors.New("sha256 pins apply to archives; pin a git source to a commit with #<sha> instead")
+	}
+	if verifier.RequiresSignature() && !isFullCommit(ref) {
+		return installer.SourceLayout{}, "", fmt.Errorf("trusted keys are configured, so git source %s must be pinned to a full commit SHA (#<sha>)", installer.RedactURL(repoURL))
+	}
+	cache, err := openCache()
+	if err != nil {
+		return installer.SourceLayout{}, "", err
+	}
+	dir, commit, err := cache.FetchGit(ctx, repoURL, ref)
+	if err != nil {
+		return installer.SourceLayout{}, "", err
+	}
+	if ref != commit {
+		fmt.Printf("Resolved %s to commit %s (pin it with --from %s#%s)\n", installer.RedactURL(location), commit, installer.RedactURL(repoURL), commit)
+	}
+	layout, err := installer.ResolveLayout(dir, subdir)
+	return layout, commit, err
+}
+
+// mergeSources fetches every source in the config's sources: list and merges
+// their skills, filtered by --tag and --lang. Skills shipped by several
+// sources come from the one with the highest priority.
+func mergeSources(ctx context.Context, sources []config.Source) ([]installer.MergedSkill, error) {
+	var merged []installer.SkillSource
+	seen := make(map[string]bool)
+	for n, s := range sources {
+		src := installer.SkillSource{
+			Name:     s.Name,
+			Priority: s.Priority,
+			Include:  s.Include,
+			Exclude:  s.Exclude,
+		}
+		location := s.URL
+		if location == "" {
+			location = s.Path
+		}
+		if src.Name == "" {
+			src.Name = installer.RedactURL(location)
+			if src.Name == "" {
+				src.Name = installer.SourceEmbedded
+			}
+		}
+		if seen[src.Name] {
+			return nil, fmt.Errorf("sources: %q is listed twice; give the entries distinct names", src.Name)
+		}
+		seen[src.Name] = true
+
+		if s.Type == installer.SourceEmbedded || (s.Type == "" && location == "") {
+			src.FS, src.Dir, src.Agents = content, "skills", content
+			merged = append(merged, src)
+			continue
+		}
+		if location == "" {
+			return nil, fmt.Errorf("sources: entry %d (%s) needs a url or path", n+1, src.Name)
+		}
+
+		switch s.Type {
+		case installer.SourceGit:
+			location = "git::" + location
+		case installer.SourceArchive:
+			location = "tar::" + location
+		case installer.SourceLocal, "":
+		default:
+			return nil, fmt.Errorf("sources: %s has unknown type %q (use embedded, local, git, or archive)", src.Name, s.Type)
+		}
+		if s.Ref != "" {
+			if s.Type != "" && s.Type != installer.SourceGit {
+				return nil, fmt.Errorf("sources: %s sets a ref, which only applies to git sources", src.Name)
+			}
+			location = strings.TrimPrefix(location, "git::")
+			location = "git::" + location + "#" + s.Ref
+		}
+
+		var layout installer.SourceLayout
+		var err error
+		if s.Type == installer.SourceLocal {
+			if s.SHA256 != "" {
+				return nil, fmt.Errorf("sources: %s sets sha256, which only applies to archives", src.Name)
+			}
+			path, subdir := installer.SplitSubdir(location)
+			layout, err = installer.ResolveLayout(path, subdir)
+		} else {
+			layout, _, err = resolvePinnedSource(ctx, location, s.SHA256)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sources: %s: %w", src.Name, err)
+		}
+		src.FS, src.Dir = os.DirFS(layout.Skills), "."
+		if layout.Agents() != "" {
+			src.Agents = os.DirFS(layout.Root)
+		}
+		merged = append(merged, src)
+	}
+	return installer.MergeSources(merged, tags, languages)
+}
+
+// collisionReport describes a skill that several sources ship, or returns ""
+// if only one does.
+func collisionReport(s installer.MergedSkill) string {
+	if len(s.Shadowed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("COLLISION: %s is in %s and %s; using %s (priority %d)",
+		s.Name(), s.Source, strings.Join(s.Shadowed, ", "), s.Source, s.Priority)
+}
+
+// fetchArchive downloads (for http and https URLs) or reads an archive
+// through the cache and returns the directory it was extracted to.
+func fetchArchive(ctx context.Context, location string, verifier *installer.Verifier) (string, error) {
+	cache, err := openCache()
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return cache.FetchURL(ctx, location, verifier)
+	}
+	return cache.FetchFile(location, verifier)
+}
+
+// newVerifier builds the archive checks for a sha256 pin and trusted keys,
+// or returns nil when there are none.
+func newVerifier(sum string, keys []string) (*installer.Verifier, error) {
+	if sum == "" && len(keys) == 0 {
+		return nil, nil
+	}
+	v := &installer.Verifier{SHA256: strings.ToLower(sum)}
+	if sum != "" {
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("invalid sha256 pin %q: expected 64 hex digits", sum)
+		}
+	}
+	for _, k := range keys {
+		key, err := installer.ParsePublicKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_keys: %w", err)
+		}
+		v.Keys = append(v.Keys, key)
+	}
+	return v, nil
+}
+
+// isFullCommit reports whether ref is a complete 40-digit commit SHA.
+func isFullCommit(ref string) bool {
+	b, err := hex.DecodeString(ref)
+	return err == nil && len(b) == 20
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var statusJSON bool
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	target, scope, manifest, err := resolveInstalled()
+	if err != nil {
+		return err
+	}
+
+	inst := installer.New(content, installer.Options{})
+	items, err := inst.Status(manifest, updateTargets(target, scope))
+	if err != nil {
+		return err
+	}
+
+	if statusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Target           string                 `json:"target"`
+			Scope            string                 `json:"scope"`
+			Source           string                 `json:"source"`
+			Commit           string                 `json:"commit,omitempty"`
+			InstalledVersion string                 `json:"installed_version"`
+			InstallerVersion string                 `json:"installer_version"`
+			Items            []installer.ItemStatus `json:"items"`
+		}{targetKey(target), scope, manifest.Source, manifest.Commit, manifest.InstallerVersion, version, items})
+	}
+
+	source := manifest.Source
+	if manifest.Commit != "" {
+		source += " (commit " + manifest.Commit + ")"
+	}
+	fmt.Printf("%s (%s), installed by v%s from %s\n\n", target.Name, scope, manifest.InstallerVersion, source)
+
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tSTATUS")
+	for _, item := range items {
+		name := item.Name
+		if item.Linked {
+			name += " (linked)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.Kind, name, item.Status)
+		counts[item.Status]++
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d up to date, %d modified, %d outdated, %d missing, %d unknown\n",
+		counts[installer.StatusUpToDate], counts[installer.StatusModified], counts[installer.StatusOutdated],
+		counts[installer.StatusMissing], counts[installer.StatusUnknown])
+	if counts[installer.StatusOutdated] > 0 && manifest.FromEmbedded() {
+		fmt.Println("Run 'skill-installer update' to bring installed content up to date.")
+	}
+	return nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	target, scope, manifest, err := resolveInstalled()
+	if err != nil {
+		return err
+	}
+	skillsDest, _, _ := installDests(target, scope)
+
+	inst := installer.New(content, installer.Options{})
+	diff, err := inst.DiffSkill(manifest, skillsDest, args[0])
+	if err != nil {
+		return err
+	}
+	if diff == "" && manifest.FromEmbedded() {
+		fmt.Printf("%s matches the embedded version.\n", args[0])
+		return nil
+	}
+	if diff == "" {
+		fmt.Printf("%s matches what was installed from %s.\n", args[0], manifest.Source)
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// resolveInstalled applies the config file's target and finds the install
+// manifest for the requested scope.
+func resolveInstalled() (Target, string, *installer.Manifest, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return Target{}, "", nil, fmt.Errorf("loading config: %w", err)
+	}
+	if targetType == "" && cfg != nil {
+		targetType = cfg.Target
+	}
+
+	scope := "project"
+	if globalInstall {
+		scope = "global"
+	}
+
+	target, manifest, err := findInstalledTarget(scope)
+	if err != nil {
+		return Target{}, "", nil, err
+	}
+	return target, scope, manifest, nil
+}
+
+// updateTargets describes where a target keeps its installed content.
+func updateTargets(target Target, scope string) installer.UpdateTargets {
+	skillsDest, agentsDest, commandsDest := installDests(target, scope)
+	dests := installer.UpdateTargets{
+		SkillsDir:   skillsDest,
+		AgentsDir:   agentsDest,
+		CommandsDir: commandsDest,
+	}
+	if target.Name == "GitHub Copilot" {
+		dests.AgentName = installer.CopilotAgentName
+	}
+	return dests
+}
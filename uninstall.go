@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+var uninstallSkills []string
+
+func runUninstall(cmd *cobra.Command, args []string) error {
+	target, _, manifest, err := resolveInstalled()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range uninstallSkills {
+		if !manifest.HasSkill(name) {
+			return fmt.Errorf("skill %q is not installed by skill-installer for %s", name, target.Name)
+		}
+	}
+
+	fmt.Printf("Uninstalling from %s (%s)...\n", target.Name, manifest.Root())
+
+	inst := installer.New(content, installer.Options{Force: force, DryRun: dryRun})
+	results, err := inst.Uninstall(manifest, uninstallSkills)
+	if err != nil {
+		return err
+	}
+
+	skipped := 0
+	for _, r := range results {
+		fmt.Println(r)
+		if strings.HasPrefix(r, "SKIP:") {
+			skipped++
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\n(dry run - no files were modified)")
+	} else if skipped > 0 {
+		fmt.Printf("\nDone. %d modified file(s) kept; re-run with --force to remove them.\n", skipped)
+	} else {
+		fmt.Println("\nDone! Uninstalled successfully.")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/futuregerald/futuregerald-claude-plugin/internal/installer"
+	"github.com/spf13/cobra"
+)
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	target, scope, manifest, err := resolveInstalled()
+	if err != nil {
+		return err
+	}
+	if manifest.Source == sourcesManifestSource {
+		return fmt.Errorf("installed from the sources in the config; re-run install to update it")
+	}
+	if !manifest.FromEmbedded() {
+		return fmt.Errorf("installed from %s; re-run install with --from to update it", manifest.Source)
+	}
+
+	var resolve installer.ConflictFunc
+	if !nonInteract {
+		resolve = askConflictChoice(reader)
+	}
+
+	fmt.Printf("Updating %s (%s) from v%s to v%s...\n", target.Name, manifest.Root(), manifest.InstallerVersion, version)
+
+	inst := installer.New(content, installer.Options{DryRun: dryRun})
+	inst.SetBackup(installer.NewBackup(manifest.Root()))
+	if !dryRun {
+		manifest.InstallerVersion = version
+	}
+	dests := updateTargets(target, scope)
+	results, err := inst.Update(manifest, dests, resolve)
+	if err != nil {
+		return err
+	}
+
+	// Linked skills are updated by re-pointing them at a new store version.
+	if len(manifest.Links) > 0 {
+		linkResults, err := relinkSkills(inst, manifest, dests.SkillsDir)
+		if err != nil {
+			return err
+		}
+		results = append(results, linkResults...)
+	}
+
+	conflicts := 0
+	for _, r := range results {
+		fmt.Println(r)
+		if strings.HasPrefix(r, "CONFLICT:") {
+			conflicts++
+		}
+	}
+	reportBackup(inst.Backup())
+
+	switch {
+	case dryRun:
+		fmt.Println("\n(dry run - no files were modified)")
+	case len(results) == 0:
+		fmt.Println("\nEverything is up to date.")
+	case conflicts > 0:
+		fmt.Printf("\nDone with %d conflicting file(s). Search for <<<<<<< markers to resolve them.\n", conflicts)
+	default:
+		fmt.Println("\nDone! Update complete.")
+	}
+	return nil
+}
+
+// relinkSkills re-points the linked skills in manifest at this version's
+// content and saves the manifest.
+func relinkSkills(inst *installer.Installer, manifest *installer.Manifest, skillsDir string) ([]string, error) {
+	storeDir, err := installer.DefaultStoreDir()
+	if err != nil {
+		return nil, err
+	}
+	inst.SetManifest(manifest)
+	results, err := inst.RelinkSkills(installer.NewStore(storeDir), "v"+version, skillsDir, manifest)
+	if err != nil || dryRun {
+		return results, err
+	}
+	return results, manifest.Save()
+}
+
+// askConflictChoice returns a ConflictFunc that asks the user how to resolve
+// each file changed both locally and upstream.
+func askConflictChoice(reader *bufio.Reader) installer.ConflictFunc {
+	return func(filePath string, local, upstream []byte) (installer.ConflictChoice, error) {
+		fmt.Printf("\n%s was changed locally and upstream.\n", filePath)
+		fmt.Println("  1) Merge with conflict markers")
+		fmt.Println("  2) Keep local version")
+		fmt.Println("  3) Take upstream version (discards local changes)")
+		fmt.Print("Enter choice [1]: ")
+
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return installer.ConflictMarkers, err
+		}
+
+		switch strings.TrimSpace(input) {
+		case "", "1":
+			return installer.ConflictMarkers, nil
+		case "2":
+			return installer.ConflictKeepLocal, nil
+		case "3":
+			return installer.ConflictTakeUpstream, nil
+		default:
+			return installer.ConflictMarkers, fmt.Errorf("invalid choice: %s", strings.TrimSpace(input))
+		}
+	}
+}